@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	agcFloor   = 0.01 // envelope level treated as silence, the gain stops rising there
+	agcMaxGain = 16.0 // +24 dB
+)
+
+var agcModes = []string{"off", "global", "per-band"}
+
+// AGCConfig controls the automatic gain applied to band energies before they
+// are clamped to 0-1
+type AGCConfig struct {
+	Mode        string        // see agcModes
+	Target      float64       // level the loudest band (global) or every band (per-band) settles at
+	Attack      time.Duration // how fast the gain drops when it gets louder
+	Release     time.Duration // and how fast it recovers when it gets quieter
+	Sensitivity float64       // multiplier applied after the AGC, also with it off
+}
+
+// AGC follows the band level with a peak envelope and scales the bands so
+// that envelope sits at the target, loud masters stop saturating and quiet
+// sources still move
+type AGC struct {
+	mode        string
+	target      float64
+	sensitivity float64
+	attack      float64 // per frame smoothing coefficients
+	release     float64
+	envelopes   []float64 // one per band, or a single one in global mode
+	gains       []float64
+}
+
+func NewAGC(cfg AGCConfig, bands int, frameRate float64) (*AGC, error) {
+	mode := strings.ToLower(cfg.Mode)
+	found := false
+	for _, m := range agcModes {
+		found = found || m == mode
+	}
+	if !found {
+		return nil, fmt.Errorf("unknown AGC mode %q (want %s)", cfg.Mode, strings.Join(agcModes, ", "))
+	}
+	if cfg.Sensitivity <= 0 {
+		return nil, fmt.Errorf("sensitivity must be positive, got %g", cfg.Sensitivity)
+	}
+	if mode != "off" && (cfg.Target <= 0 || cfg.Target > 1) {
+		return nil, fmt.Errorf("AGC target must be between 0 and 1, got %g", cfg.Target)
+	}
+	if mode != "off" && (cfg.Attack <= 0 || cfg.Release <= 0) {
+		return nil, fmt.Errorf("AGC attack and release must be positive")
+	}
+
+	n := 1
+	if mode == "per-band" {
+		n = bands
+	}
+	agc := &AGC{
+		mode:        mode,
+		target:      cfg.Target,
+		sensitivity: cfg.Sensitivity,
+		attack:      smoothingCoefficient(cfg.Attack, frameRate),
+		release:     smoothingCoefficient(cfg.Release, frameRate),
+		envelopes:   make([]float64, n),
+		gains:       make([]float64, n),
+	}
+	// Start at unity gain so the first frames aren't blown up
+	for i := range agc.envelopes {
+		agc.envelopes[i] = cfg.Target
+		agc.gains[i] = 1
+	}
+	return agc, nil
+}
+
+// smoothingCoefficient is the one-pole coefficient reaching ~63% after tau
+func smoothingCoefficient(tau time.Duration, frameRate float64) float64 {
+	if tau <= 0 {
+		return 1
+	}
+	return 1 - math.Exp(-1/(tau.Seconds()*frameRate))
+}
+
+// Apply scales the raw band energies in place and returns the overall gain
+// (the mean gain in per-band mode), sensitivity included
+func (agc *AGC) Apply(energies []float64) float64 {
+	if agc.mode == "off" {
+		for i := range energies {
+			energies[i] *= agc.sensitivity
+		}
+		return agc.sensitivity
+	}
+
+	if agc.mode == "global" {
+		peak := 0.0
+		for _, e := range energies {
+			peak = math.Max(peak, e)
+		}
+		agc.gains[0] = agc.follow(0, peak)
+		for i := range energies {
+			energies[i] *= agc.gains[0] * agc.sensitivity
+		}
+		return agc.gains[0] * agc.sensitivity
+	}
+
+	var sum float64
+	for i := range energies {
+		agc.gains[i] = agc.follow(i, energies[i])
+		energies[i] *= agc.gains[i] * agc.sensitivity
+		sum += agc.gains[i]
+	}
+	return sum / float64(len(energies)) * agc.sensitivity
+}
+
+// Gain is the gain applied to band i by the last Apply, sensitivity included
+func (agc *AGC) Gain(i int) float64 {
+	if agc.mode == "off" {
+		return agc.sensitivity
+	}
+	return agc.gains[min(i, len(agc.gains)-1)] * agc.sensitivity
+}
+
+// follow updates one envelope and returns the gain that brings it to the target
+func (agc *AGC) follow(i int, level float64) float64 {
+	coeff := agc.release
+	if level > agc.envelopes[i] {
+		coeff = agc.attack
+	}
+	agc.envelopes[i] += (level - agc.envelopes[i]) * coeff
+	return math.Min(agc.target/math.Max(agc.envelopes[i], agcFloor), agcMaxGain)
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"strings"
+)
+
+var analyzerNames = []string{"fft", "mel", "cqt"}
+
+const cqtMaxHistory = 16384 // longest constant-Q kernel, ~0.37s at 44.1kHz
+
+// Analyzer turns one channel's analysis frame into a level per band. The FFT
+// analyzer reads the bins straight, the others spend some CPU on resolution
+// that stays even across the musical range.
+type Analyzer interface {
+	// History is how many recent samples Analyze wants next to the FFT, 0 for none
+	History() int
+	// Analyze writes one level per band. coeffs is the FFT of the windowed
+	// frame, samples the unwindowed history oldest first.
+	Analyze(coeffs []complex128, samples []float64, levels []float64)
+}
+
+// NewAnalyzer builds the named analyzer for a set of bands. magScale is the
+// FFT magnitude calibration, see referenceFFTSize.
+func NewAnalyzer(name string, bands []FrequencyBand, sampleRate, fftSize, hopSize int, magScale float64) (Analyzer, error) {
+	switch strings.ToLower(name) {
+	case "", "fft":
+		return &fftAnalyzer{
+			ranges:   bandBinRanges(bands, sampleRate, fftSize, fftSize/2+1),
+			magScale: magScale,
+		}, nil
+	case "mel":
+		return newMelAnalyzer(bands, sampleRate, fftSize, magScale), nil
+	case "cqt":
+		return newCQTAnalyzer(bands, sampleRate, fftSize, hopSize), nil
+	default:
+		return nil, fmt.Errorf("unknown analyzer %q (available: %s)", name, strings.Join(analyzerNames, ", "))
+	}
+}
+
+// weightedMagnitude is the magnitude curve all analyzers share, it lifts
+// strong partials over the noise floor
+func weightedMagnitude(mag float64) float64 {
+	return mag * math.Log1p(mag)
+}
+
+// fftAnalyzer is the RMS of the weighted bin magnitudes inside each band.
+// Bass bands only get a couple of bins while the top ones get hundreds.
+type fftAnalyzer struct {
+	ranges   []binRange
+	magScale float64
+}
+
+func (fa *fftAnalyzer) History() int { return 0 }
+
+func (fa *fftAnalyzer) Analyze(coeffs []complex128, _ []float64, levels []float64) {
+	for i, r := range fa.ranges {
+		if r.hi <= r.lo {
+			levels[i] = 0
+			continue
+		}
+		var sum float64
+		for j := r.lo; j < r.hi; j++ {
+			weighted := weightedMagnitude(cmplx.Abs(coeffs[j]) * fa.magScale)
+			sum += weighted * weighted
+		}
+		levels[i] = math.Sqrt(sum / float64(r.hi-r.lo))
+	}
+}
+
+// melFilter is a triangular filter over a run of FFT bins
+type melFilter struct {
+	lo      int
+	weights []float64
+	total   float64
+}
+
+// melAnalyzer runs a triangular filterbank peaking at each band's centre on
+// the mel scale. Neighbouring bands overlap, so narrow bass bands interpolate
+// between bins instead of sharing or missing them.
+type melAnalyzer struct {
+	filters  []melFilter
+	magScale float64
+}
+
+func newMelAnalyzer(bands []FrequencyBand, sampleRate, fftSize int, magScale float64) *melAnalyzer {
+	binWidth := float64(sampleRate) / float64(fftSize)
+	bins := fftSize/2 + 1
+
+	centres := make([]float64, len(bands))
+	for i, b := range bands {
+		centres[i] = melToHz((hzToMel(b.MinFreq) + hzToMel(b.MaxFreq)) / 2)
+	}
+
+	filters := make([]melFilter, len(bands))
+	for i, b := range bands {
+		left, right := b.MinFreq, b.MaxFreq
+		if i > 0 {
+			left = centres[i-1]
+		}
+		if i < len(bands)-1 {
+			right = centres[i+1]
+		}
+		centre := centres[i]
+
+		lo := max(int(math.Floor(left/binWidth)), 0)
+		hi := min(int(math.Ceil(right/binWidth)), bins-1)
+		f := melFilter{lo: lo, weights: make([]float64, hi-lo+1)}
+		for j := lo; j <= hi; j++ {
+			freq := float64(j) * binWidth
+			var w float64
+			switch {
+			case freq >= left && freq <= centre && centre > left:
+				w = (freq - left) / (centre - left)
+			case freq > centre && freq <= right && right > centre:
+				w = (right - freq) / (right - centre)
+			}
+			f.weights[j-lo] = w
+			f.total += w
+		}
+
+		// Narrower than a bin, interpolate between the two around the centre
+		if f.total == 0 {
+			pos := centre / binWidth
+			f.lo = min(int(pos), bins-2)
+			frac := math.Min(pos-float64(f.lo), 1)
+			f.weights = []float64{1 - frac, frac}
+			f.total = 1
+		}
+		filters[i] = f
+	}
+	return &melAnalyzer{filters: filters, magScale: magScale}
+}
+
+func (ma *melAnalyzer) History() int { return 0 }
+
+func (ma *melAnalyzer) Analyze(coeffs []complex128, _ []float64, levels []float64) {
+	for i, f := range ma.filters {
+		var sum float64
+		for k, w := range f.weights {
+			if w == 0 {
+				continue
+			}
+			weighted := weightedMagnitude(cmplx.Abs(coeffs[f.lo+k]) * ma.magScale)
+			sum += w * weighted * weighted
+		}
+		levels[i] = math.Sqrt(sum / f.total)
+	}
+}
+
+// cqtKernel is one band of the constant-Q transform: a windowed complex
+// sinusoid at the band centre, long enough to resolve the band's width
+type cqtKernel struct {
+	cos, sin []float64 // window folded in
+	stride   int       // short kernels are slid over the last hop and averaged
+	count    int
+	scale    float64
+}
+
+// cqtAnalyzer evaluates a constant-Q transform directly on the sample history.
+// Every band gets a kernel as long as its bandwidth needs, so bass bands see
+// up to cqtMaxHistory samples while the treble stays fast.
+type cqtAnalyzer struct {
+	kernels []cqtKernel
+	history int
+}
+
+func newCQTAnalyzer(bands []FrequencyBand, sampleRate, fftSize, hopSize int) *cqtAnalyzer {
+	ca := &cqtAnalyzer{kernels: make([]cqtKernel, len(bands)), history: hopSize}
+	for i, b := range bands {
+		// Blackman-Harris keeps the short treble kernels from picking up the
+		// bass, its main lobe is about two bins wide
+		length := int(math.Round(2 * float64(sampleRate) / (b.MaxFreq - b.MinFreq)))
+		length = max(min(length, cqtMaxHistory), 32)
+		centre := b.centreFrequency()
+		window, _ := makeWindow("blackman-harris", length)
+
+		k := cqtKernel{
+			cos:    make([]float64, length),
+			sin:    make([]float64, length),
+			stride: max(length/2, 1),
+		}
+		var windowSum float64
+		for n, w := range window {
+			phase := 2 * math.Pi * centre * float64(n) / float64(sampleRate)
+			k.cos[n] = w * math.Cos(phase)
+			k.sin[n] = w * math.Sin(phase)
+			windowSum += w
+		}
+		// Kernels shorter than a hop would only see a sliver of it
+		k.count = 1
+		if length < hopSize {
+			k.count = (hopSize-length)/k.stride + 1
+		}
+		// Same calibration as the FFT bins, normalized to spectral density so
+		// a band reads like the RMS of the bins it covers
+		k.scale = referenceFFTSize / windowSum * math.Sqrt(float64(length)/float64(fftSize))
+
+		ca.kernels[i] = k
+		ca.history = max(ca.history, length)
+	}
+	return ca
+}
+
+func (ca *cqtAnalyzer) History() int { return ca.history }
+
+func (ca *cqtAnalyzer) Analyze(_ []complex128, samples []float64, levels []float64) {
+	for i, k := range ca.kernels {
+		var power float64
+		for m := range k.count {
+			start := len(samples) - len(k.cos) - m*k.stride
+			var re, im float64
+			for n, x := range samples[start : start+len(k.cos)] {
+				re += x * k.cos[n]
+				im -= x * k.sin[n]
+			}
+			power += re*re + im*im
+		}
+		levels[i] = weightedMagnitude(math.Sqrt(power/float64(k.count)) * k.scale)
+	}
+}
@@ -0,0 +1,367 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mewkiz/flac"
+	"github.com/mewkiz/flac/frame"
+)
+
+// audioDecoder yields interleaved float32 frames from an audio file
+type audioDecoder interface {
+	SampleRate() int
+	Channels() int
+	Encoding() SampleFormat
+	TotalFrames() int64
+	// ReadFrames fills dst (a multiple of Channels long) and returns the
+	// number of frames read; io.EOF once the file is exhausted
+	ReadFrames(dst []float32) (int, error)
+	SeekFrame(frame int64) error
+	Close() error
+}
+
+// openAudioDecoder picks a decoder from the file extension
+func openAudioDecoder(path string) (audioDecoder, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".wav", ".wave":
+		return openWAVDecoder(path)
+	case ".flac":
+		return openFLACDecoder(path)
+	default:
+		return nil, fmt.Errorf("unsupported audio file %q (want .wav or .flac)", filepath.Base(path))
+	}
+}
+
+const (
+	wavFormatPCM        = 0x0001
+	wavFormatFloat      = 0x0003
+	wavFormatExtensible = 0xFFFE
+)
+
+// wavDecoder reads uncompressed RIFF/WAVE files: 8/16/24/32-bit integer
+// PCM and 32/64-bit float, including WAVE_FORMAT_EXTENSIBLE headers
+type wavDecoder struct {
+	file        *os.File
+	format      uint16
+	channels    int
+	sampleRate  int
+	bits        int
+	blockAlign  int
+	dataOffset  int64
+	totalFrames int64
+	position    int64
+	raw         []byte
+}
+
+func openWAVDecoder(path string) (*wavDecoder, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open wav file: %w", err)
+	}
+
+	dec := &wavDecoder{file: f}
+	if err := dec.readHeader(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return dec, nil
+}
+
+func (wd *wavDecoder) readHeader() error {
+	var riff [12]byte
+	if _, err := io.ReadFull(wd.file, riff[:]); err != nil {
+		return fmt.Errorf("failed to read RIFF header: %w", err)
+	}
+	if string(riff[0:4]) != "RIFF" || string(riff[8:12]) != "WAVE" {
+		return errors.New("not a RIFF/WAVE file")
+	}
+
+	haveFmt := false
+	offset := int64(12)
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(wd.file, chunk[:]); err != nil {
+			return fmt.Errorf("no data chunk found: %w", err)
+		}
+		id := string(chunk[0:4])
+		size := int64(binary.LittleEndian.Uint32(chunk[4:8]))
+		offset += 8
+
+		switch id {
+		case "fmt ":
+			body := make([]byte, size)
+			if _, err := io.ReadFull(wd.file, body); err != nil {
+				return fmt.Errorf("failed to read fmt chunk: %w", err)
+			}
+			if err := wd.parseFmt(body); err != nil {
+				return err
+			}
+			haveFmt = true
+
+		case "data":
+			if !haveFmt {
+				return errors.New("data chunk before fmt chunk")
+			}
+			wd.dataOffset = offset
+
+			// Streamed writers leave the size at 0 or 0xFFFFFFFF, trust the file length then
+			if info, err := wd.file.Stat(); err == nil && (size == 0 || size == 0xFFFFFFFF || offset+size > info.Size()) {
+				size = info.Size() - offset
+			}
+			wd.totalFrames = size / int64(wd.blockAlign)
+			return nil
+
+		default:
+			if _, err := wd.file.Seek(size, io.SeekCurrent); err != nil {
+				return fmt.Errorf("failed to skip %q chunk: %w", id, err)
+			}
+		}
+
+		offset += size
+		// Chunks are word aligned
+		if size%2 == 1 {
+			if _, err := wd.file.Seek(1, io.SeekCurrent); err != nil {
+				return err
+			}
+			offset++
+		}
+	}
+}
+
+func (wd *wavDecoder) parseFmt(body []byte) error {
+	if len(body) < 16 {
+		return errors.New("fmt chunk too short")
+	}
+
+	wd.format = binary.LittleEndian.Uint16(body[0:2])
+	wd.channels = int(binary.LittleEndian.Uint16(body[2:4]))
+	wd.sampleRate = int(binary.LittleEndian.Uint32(body[4:8]))
+	wd.blockAlign = int(binary.LittleEndian.Uint16(body[12:14]))
+	wd.bits = int(binary.LittleEndian.Uint16(body[14:16]))
+
+	if wd.format == wavFormatExtensible {
+		if len(body) < 26 {
+			return errors.New("extensible fmt chunk too short")
+		}
+		// First two bytes of the SubFormat GUID carry the real format tag
+		wd.format = binary.LittleEndian.Uint16(body[24:26])
+	}
+
+	switch {
+	case wd.format == wavFormatPCM && (wd.bits == 8 || wd.bits == 16 || wd.bits == 24 || wd.bits == 32):
+	case wd.format == wavFormatFloat && (wd.bits == 32 || wd.bits == 64):
+	default:
+		return fmt.Errorf("unsupported wav encoding (format=%#x, bits=%d)", wd.format, wd.bits)
+	}
+
+	if wd.channels < 1 || wd.sampleRate < 1 || wd.blockAlign < wd.channels*wd.bits/8 {
+		return fmt.Errorf("invalid wav header (channels=%d, rate=%d, blockAlign=%d)", wd.channels, wd.sampleRate, wd.blockAlign)
+	}
+	return nil
+}
+
+func (wd *wavDecoder) SampleRate() int    { return wd.sampleRate }
+func (wd *wavDecoder) Channels() int      { return wd.channels }
+func (wd *wavDecoder) TotalFrames() int64 { return wd.totalFrames }
+
+func (wd *wavDecoder) Encoding() SampleFormat {
+	switch {
+	case wd.format == wavFormatFloat:
+		return SampleFormat(fmt.Sprintf("f%dle", wd.bits))
+	case wd.bits == 8:
+		return "u8"
+	default:
+		return SampleFormat(fmt.Sprintf("s%dle", wd.bits))
+	}
+}
+
+func (wd *wavDecoder) ReadFrames(dst []float32) (int, error) {
+	frames := len(dst) / wd.channels
+	if remaining := wd.totalFrames - wd.position; int64(frames) > remaining {
+		frames = int(remaining)
+	}
+	if frames <= 0 {
+		return 0, io.EOF
+	}
+
+	need := frames * wd.blockAlign
+	if cap(wd.raw) < need {
+		wd.raw = make([]byte, need)
+	}
+	raw := wd.raw[:need]
+
+	n, err := io.ReadFull(wd.file, raw)
+	frames = n / wd.blockAlign
+	if frames == 0 {
+		if err == nil || errors.Is(err, io.ErrUnexpectedEOF) {
+			err = io.EOF
+		}
+		return 0, err
+	}
+
+	bytesPerSample := wd.bits / 8
+	for f := range frames {
+		block := raw[f*wd.blockAlign:]
+		for c := range wd.channels {
+			dst[f*wd.channels+c] = wd.decodeSample(block[c*bytesPerSample:])
+		}
+	}
+	wd.position += int64(frames)
+
+	return frames, nil
+}
+
+func (wd *wavDecoder) decodeSample(b []byte) float32 {
+	if wd.format == wavFormatFloat {
+		if wd.bits == 64 {
+			return float32(math.Float64frombits(binary.LittleEndian.Uint64(b)))
+		}
+		return math.Float32frombits(binary.LittleEndian.Uint32(b))
+	}
+
+	switch wd.bits {
+	case 8:
+		return (float32(b[0]) - 128) / 128
+	case 16:
+		return float32(int16(binary.LittleEndian.Uint16(b))) / 32768
+	case 24:
+		v := int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24) >> 8
+		return float32(v) / 8388608
+	default:
+		return float32(int32(binary.LittleEndian.Uint32(b))) / 2147483648
+	}
+}
+
+func (wd *wavDecoder) SeekFrame(frame int64) error {
+	frame = max(0, min(frame, wd.totalFrames))
+	if _, err := wd.file.Seek(wd.dataOffset+frame*int64(wd.blockAlign), io.SeekStart); err != nil {
+		return fmt.Errorf("wav seek failed: %w", err)
+	}
+	wd.position = frame
+	return nil
+}
+
+func (wd *wavDecoder) Close() error {
+	return wd.file.Close()
+}
+
+// flacDecoder wraps mewkiz/flac. The stream API only moves forward, so
+// seeking backwards reopens the file and skips whole frames.
+type flacDecoder struct {
+	path     string
+	stream   *flac.Stream
+	channels int
+	scale    float32
+	position int64 // frame index of the next sample returned by ReadFrames
+
+	pending    *frame.Frame
+	pendingPos int
+}
+
+func openFLACDecoder(path string) (*flacDecoder, error) {
+	stream, err := flac.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open flac file: %w", err)
+	}
+
+	if stream.Info.NChannels < 1 || stream.Info.BitsPerSample < 1 {
+		stream.Close()
+		return nil, fmt.Errorf("invalid flac stream info (channels=%d, bits=%d)", stream.Info.NChannels, stream.Info.BitsPerSample)
+	}
+
+	return &flacDecoder{
+		path:     path,
+		stream:   stream,
+		channels: int(stream.Info.NChannels),
+		scale:    float32(int64(1) << (stream.Info.BitsPerSample - 1)),
+	}, nil
+}
+
+func (fd *flacDecoder) SampleRate() int    { return int(fd.stream.Info.SampleRate) }
+func (fd *flacDecoder) Channels() int      { return fd.channels }
+func (fd *flacDecoder) TotalFrames() int64 { return int64(fd.stream.Info.NSamples) }
+
+func (fd *flacDecoder) Encoding() SampleFormat {
+	return SampleFormat(fmt.Sprintf("flac/s%d", fd.stream.Info.BitsPerSample))
+}
+
+func (fd *flacDecoder) ReadFrames(dst []float32) (int, error) {
+	want := len(dst) / fd.channels
+	got := 0
+
+	for got < want {
+		if fd.pending == nil || fd.pendingPos >= len(fd.pending.Subframes[0].Samples) {
+			next, err := fd.stream.ParseNext()
+			if err != nil {
+				if got > 0 && err == io.EOF {
+					break
+				}
+				return got, err
+			}
+			fd.pending = next
+			fd.pendingPos = 0
+		}
+
+		samples := len(fd.pending.Subframes[0].Samples)
+		for fd.pendingPos < samples && got < want {
+			for c := range fd.channels {
+				dst[got*fd.channels+c] = float32(fd.pending.Subframes[c].Samples[fd.pendingPos]) / fd.scale
+			}
+			fd.pendingPos++
+			got++
+		}
+	}
+
+	fd.position += int64(got)
+	return got, nil
+}
+
+func (fd *flacDecoder) SeekFrame(target int64) error {
+	target = max(0, target)
+	if total := fd.TotalFrames(); total > 0 {
+		target = min(target, total)
+	}
+
+	if target < fd.position {
+		stream, err := flac.Open(fd.path)
+		if err != nil {
+			return fmt.Errorf("flac reopen failed: %w", err)
+		}
+		fd.stream.Close()
+		fd.stream = stream
+		fd.position = 0
+		fd.pending = nil
+	}
+
+	// Drop what's left of the current frame, then whole frames, then the head of the last one
+	for fd.position < target {
+		if fd.pending == nil || fd.pendingPos >= len(fd.pending.Subframes[0].Samples) {
+			next, err := fd.stream.ParseNext()
+			if err != nil {
+				if err == io.EOF {
+					return nil
+				}
+				return fmt.Errorf("flac seek failed: %w", err)
+			}
+			fd.pending = next
+			fd.pendingPos = 0
+		}
+
+		left := int64(len(fd.pending.Subframes[0].Samples) - fd.pendingPos)
+		skip := min(left, target-fd.position)
+		fd.pendingPos += int(skip)
+		fd.position += skip
+	}
+	return nil
+}
+
+func (fd *flacDecoder) Close() error {
+	return fd.stream.Close()
+}
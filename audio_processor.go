@@ -1,176 +1,346 @@
 package main
 
 import (
+	"fmt"
 	"log"
 	"math"
-	"math/cmplx"
 	"time"
 
 	"github.com/gordonklaus/portaudio"
 	"gonum.org/v1/gonum/dsp/fourier"
 )
 
+// AnalysisConfig controls how captured samples are framed for the FFT
+type AnalysisConfig struct {
+	FFTSize  int    // samples per FFT frame
+	HopSize  int    // frames between two analyses
+	Window   string // see analysisWindows
+	Analyzer string // see analyzerNames
+	Bands    []FrequencyBand
+	AGC      AGCConfig
+	Chaos    string // see ParseChaosMapping
+
+	IdleAfter     time.Duration // silence before the frames are marked idle, 0 never
+	IdleThreshold float64       // dBFS below which the input counts as silent
+}
+
+// The band scale factors were tuned against an unwindowed 2048-point FFT,
+// magnitudes are normalized back to that so they stay calibrated
+const referenceFFTSize = 2048
+
+// Per-band scaling to compensate for natural frequency roll-off of the
+// classic bands, other layouts interpolate it (see bandScaleFactor)
+// Balanced for "Liquid" movement: responsive but not jittery
+var bandScaleFactors = [9]float64{
+	10.0, // Sub-Bass (Thump)
+	12.0, // Bass
+	14.0, // Low Mids
+	16.0, // Low-Mid
+	18.0, // Mids
+	22.0, // Upper Mids
+	26.0, // Presence
+	30.0, // Highs
+	35.0, // Air
+}
+
+// frameBuffers is how many frames the processor cycles through for the
+// slices it hands out. The processor keeps running while frames sit in the
+// channel, are dropped or are skipped by the TUI, so a frame's slices get
+// rewritten frameBuffers frames after it was emitted, concurrently with
+// whoever still reads them. Anything kept past the Update that received the
+// frame has to be copied.
+const frameBuffers = 32
+
+// frameBuffer holds the slices of one emitted frame
+type frameBuffer struct {
+	bands      []float64
+	bandOnsets []float64
+	bandsLeft  []float64
+	bandsRight []float64
+	points     [][2]float32
+	waveLeft   []float32
+	waveRight  []float32
+	spectrum   []float32
+}
+
 type AudioProcessor struct {
-	stream         *portaudio.Stream
-	buffer         []float32
-	analysisBuffer []float32
-	sampleRate     int
-	bufferSize     int
+	stream     *portaudio.Stream
+	sampleRate int
+	channels   int
+	fftSize    int
+	hopSize    int
+	window     []float64
+
+	bands        []FrequencyBand
+	analyzer     Analyzer
+	levelsLeft   []float64
+	levelsRight  []float64
+	scaleFactors []float64
+	energies     []float64
+	onsets       *OnsetDetector
+	tempo        *TempoTracker
+	loudness     *LoudnessMeter
+	agc          *AGC
+	chroma       *ChromaAnalyzer
+	pitch        *PitchDetector
+	silence      *SilenceDetector
+	chaosMap     ChaosMapping
+	magnitudes   []float64 // scratch for the spectral features, reused by logSpectrum
+	spectrumGain float64   // turns those magnitudes into full-scale amplitudes
+	bandOnsets   []float64
+	bandsLeft    []float64
+	bandsRight   []float64
+
+	// Ring buffers holding the last fftSize frames of each channel, or more
+	// if the analyzer wants a longer history
+	ringLeft  []float64
+	ringRight []float64
+	ringPos   int
+	sinceHop  int
+
+	// Unwindowed history for the analyzer, oldest first
+	historyLeft  []float64
+	historyRight []float64
+	historyMono  []float64 // for the pitch detector
+
+	// The pitch detector runs every pitchEvery hops, the result is held in between
+	pitchEvery  int
+	sincePitch  int
+	lastPitch   float64
+	lastClarity float64
+
+	// Preallocated FFT input/output so analysis doesn't allocate
+	windowedLeft  []float64
+	windowedRight []float64
+	coeffsLeft    []complex128
+	coeffsRight   []complex128
+
+	// Storage for the slices of the frames handed out, see frameBuffers
+	frameRing []frameBuffer
+	nextFrame int
+
 	noiseGen       *NoiseGenerator
 	fft            *fourier.FFT
 	mediaProvider  *MediaSessionProvider
+	metadataSource MetadataSource
+}
+
+// MetadataSource overrides the live media session as the source of
+// AudioFrame.Metadata, e.g. while replaying a recording
+type MetadataSource interface {
+	CurrentMetadata() AudioMetadata
 }
 
-func NewAudioProcessor(sampleRate, bufferSize int) (*AudioProcessor, error) {
+func NewAudioProcessor(format CaptureFormat, analysis AnalysisConfig) (*AudioProcessor, error) {
+	if format.Channels < 1 {
+		return nil, fmt.Errorf("invalid channel count %d", format.Channels)
+	}
+	fftSize, hopSize := analysis.FFTSize, analysis.HopSize
+	if fftSize < 64 {
+		return nil, fmt.Errorf("FFT size must be at least 64, got %d", fftSize)
+	}
+	if hopSize < 1 || hopSize > fftSize {
+		return nil, fmt.Errorf("hop must be between 1 and the FFT size (%d), got %d", fftSize, hopSize)
+	}
+
+	bands := analysis.Bands
+	if len(bands) == 0 {
+		bands = frequencyBands
+	}
+	scaleFactors := make([]float64, len(bands))
+	for i, band := range bands {
+		scaleFactors[i] = bandScaleFactor(band.centreFrequency())
+	}
+	bins := fftSize/2 + 1
+	binRanges := bandBinRanges(bands, format.SampleRate, fftSize, bins)
+
+	window, err := makeWindow(analysis.Window, fftSize)
+	if err != nil {
+		return nil, err
+	}
+	var windowSum float64
+	for _, w := range window {
+		windowSum += w
+	}
+
+	magScale := referenceFFTSize / windowSum
+	analyzer, err := NewAnalyzer(analysis.Analyzer, bands, format.SampleRate, fftSize, hopSize, magScale)
+	if err != nil {
+		return nil, err
+	}
+	pitch := NewPitchDetector(format.SampleRate)
+	ringSize := max(fftSize, analyzer.History(), pitch.History())
+
+	chaosMap, err := ParseChaosMapping(analysis.Chaos)
+	if err != nil {
+		return nil, err
+	}
+
+	frameRate := float64(format.SampleRate) / float64(hopSize)
+	agc, err := NewAGC(analysis.AGC, len(bands), frameRate)
+	if err != nil {
+		return nil, err
+	}
+
 	mediaProvider, err := NewMediaSessionProvider()
 	if err != nil {
 		log.Printf("Error loading metadata provider: %v", err)
 		mediaProvider = nil
 	}
 
+	LogInfo("Analysis: %s analyzer, %s window, FFT %d, hop %d (%.1f frames/s), %d bands, AGC %s",
+		analysis.Analyzer, analysis.Window, fftSize, hopSize, frameRate, len(bands), analysis.AGC.Mode)
+
 	return &AudioProcessor{
-		sampleRate:     sampleRate,
-		bufferSize:     bufferSize,
-		buffer:         make([]float32, bufferSize),
-		analysisBuffer: make([]float32, bufferSize),
-		fft:            fourier.NewFFT(bufferSize),
-		mediaProvider:  mediaProvider,
+		sampleRate:    format.SampleRate,
+		channels:      format.Channels,
+		fftSize:       fftSize,
+		hopSize:       hopSize,
+		window:        window,
+		bands:         bands,
+		analyzer:      analyzer,
+		levelsLeft:    make([]float64, len(bands)),
+		levelsRight:   make([]float64, len(bands)),
+		scaleFactors:  scaleFactors,
+		energies:      make([]float64, len(bands)),
+		onsets:        NewOnsetDetector(format.SampleRate, hopSize, binRanges, bins),
+		tempo:         NewTempoTracker(format.SampleRate, hopSize),
+		loudness:      NewLoudnessMeter(format.SampleRate, format.Channels),
+		agc:           agc,
+		chroma:        NewChromaAnalyzer(format.SampleRate, fftSize, hopSize),
+		pitch:         pitch,
+		silence:       NewSilenceDetector(analysis.IdleThreshold, analysis.IdleAfter, format.SampleRate, hopSize),
+		chaosMap:      chaosMap,
+		magnitudes:    make([]float64, bins),
+		spectrumGain:  2 / windowSum,
+		bandOnsets:    make([]float64, len(bands)),
+		bandsLeft:     make([]float64, len(bands)),
+		bandsRight:    make([]float64, len(bands)),
+		ringLeft:      make([]float64, ringSize),
+		ringRight:     make([]float64, ringSize),
+		historyLeft:   make([]float64, analyzer.History()),
+		historyRight:  make([]float64, analyzer.History()),
+		historyMono:   make([]float64, pitch.History()),
+		pitchEvery:    max(int(pitchInterval.Seconds()*frameRate), 1),
+		windowedLeft:  make([]float64, fftSize),
+		windowedRight: make([]float64, fftSize),
+		coeffsLeft:    make([]complex128, bins),
+		coeffsRight:   make([]complex128, bins),
+		frameRing:     make([]frameBuffer, frameBuffers),
+		fft:           fourier.NewFFT(fftSize),
+		mediaProvider: mediaProvider,
 	}, nil
 }
 
-// Builds buffer to perform FFT and calculates band "energy"
-func (ap *AudioProcessor) ProcessBuffer(buffer []float32) AudioFrame {
+// bandBinRanges maps each band to the FFT bins it covers
+func bandBinRanges(bands []FrequencyBand, sampleRate, fftSize, bins int) []binRange {
+	binWidth := float64(sampleRate) / float64(fftSize)
+	ranges := make([]binRange, len(bands))
+	for i, fb := range bands {
+		minBin := int(fb.MinFreq / binWidth)
+		maxBin := int(fb.MaxFreq / binWidth)
+
+		// Narrow low bands can fall between two bins, give each at least one
+		if maxBin <= minBin {
+			maxBin = minBin + 1
+		}
+
+		// clamp and validate
+		minBin = max(minBin, 0)
+		maxBin = min(maxBin, bins)
+		ranges[i] = binRange{lo: minBin, hi: max(maxBin, minBin)}
+	}
+	return ranges
+}
+
+// SetMetadataSource replaces the media session provider as the metadata source
+func (ap *AudioProcessor) SetMetadataSource(src MetadataSource) {
+	ap.metadataSource = src
+}
+
+// ProcessBuffer pushes interleaved samples into the analysis ring and calls
+// emit once for every hop completed. Buffers can hold any number of frames,
+// so the analysis rate only depends on the hop size. The frames' slices are
+// reused after frameBuffers more frames, see there.
+func (ap *AudioProcessor) ProcessBuffer(buffer []float32, emit func(AudioFrame)) {
 	defer func() {
 		if r := recover(); r != nil {
 			LogPanic(r, "ProcessBuffer internal")
 		}
 	}()
 
-	// Validate input
-	if len(buffer) == 0 {
-		LogError("ProcessBuffer received empty buffer")
-		return AudioFrame{
-			Bands:      [9]float64{},
-			ChaosLevel: 0,
-			Timestamp:  time.Now(),
-			Metadata:   DefaultMetadata(),
-		}
+	if len(buffer)%ap.channels != 0 {
+		LogError("ProcessBuffer received %d samples, not a multiple of %d channels", len(buffer), ap.channels)
+		return
 	}
 
-	// Split stereo into L and R channels
-	// Stereo format: [L, R, L, R, L, R, ...]
-	var leftChannel, rightChannel []float64
-	if len(buffer)%2 == 0 {
-		// Stereo input
-		leftChannel = make([]float64, len(buffer)/2)
-		rightChannel = make([]float64, len(buffer)/2)
-		for i := 0; i < len(buffer); i += 2 {
-			leftChannel[i/2] = float64(buffer[i])
-			rightChannel[i/2] = float64(buffer[i+1])
+	// Loudness is metered on every sample, not per FFT frame
+	ap.loudness.Process(buffer)
+
+	// Split interleaved input into L and R channels using the backend's
+	// channel count. Mono is duplicated, anything past 2 channels is ignored.
+	for i := 0; i < len(buffer); i += ap.channels {
+		left := float64(buffer[i])
+		right := left
+		if ap.channels > 1 {
+			right = float64(buffer[i+1])
 		}
-	} else {
-		// Mono fallback
-		leftChannel = make([]float64, len(buffer))
-		rightChannel = make([]float64, len(buffer))
-		for i, sample := range buffer {
-			leftChannel[i] = float64(sample)
-			rightChannel[i] = float64(sample)
+		ap.ringLeft[ap.ringPos] = left
+		ap.ringRight[ap.ringPos] = right
+		ap.ringPos = (ap.ringPos + 1) % len(ap.ringLeft)
+
+		ap.sinceHop++
+		if ap.sinceHop == ap.hopSize {
+			ap.sinceHop = 0
+			emit(ap.analyze())
 		}
 	}
+}
 
-	// Run FFT on both channels independently in parallel
-	type fftResult struct {
-		coeffs []complex128
-		err    error
+// analyze windows the ring contents, runs the FFT and calculates band "energy"
+func (ap *AudioProcessor) analyze() AudioFrame {
+	buf := &ap.frameRing[ap.nextFrame]
+	ap.nextFrame = (ap.nextFrame + 1) % len(ap.frameRing)
+
+	// Unroll the last fftSize samples of the rings oldest first while
+	// applying the window
+	ringSize := len(ap.ringLeft)
+	start := (ap.ringPos - ap.fftSize + ringSize) % ringSize
+	for i := range ap.fftSize {
+		pos := (start + i) % ringSize
+		ap.windowedLeft[i] = ap.ringLeft[pos] * ap.window[i]
+		ap.windowedRight[i] = ap.ringRight[pos] * ap.window[i]
 	}
-
-	leftResult := make(chan fftResult, 1)
-	rightResult := make(chan fftResult, 1)
-
-	// Left channel FFT
-	go func() {
-		coeffs := ap.fft.Coefficients(nil, leftChannel)
-		leftResult <- fftResult{coeffs: coeffs, err: nil}
-	}()
-
-	// Right channel FFT
-	go func() {
-		coeffs := ap.fft.Coefficients(nil, rightChannel)
-		rightResult <- fftResult{coeffs: coeffs, err: nil}
-	}()
-
-	// Wait for both FFTs to complete
-	leftFFT := <-leftResult
-	rightFFT := <-rightResult
-
-	if len(leftFFT.coeffs) == 0 || len(rightFFT.coeffs) == 0 {
-		LogError("FFT returned empty coefficients")
-		return AudioFrame{
-			Bands:      [9]float64{},
-			ChaosLevel: 0,
-			Timestamp:  time.Now(),
-			Metadata:   DefaultMetadata(),
+	if n := len(ap.historyLeft); n > 0 {
+		start = (ap.ringPos - n + ringSize) % ringSize
+		for i := range n {
+			pos := (start + i) % ringSize
+			ap.historyLeft[i] = ap.ringLeft[pos]
+			ap.historyRight[i] = ap.ringRight[pos]
 		}
 	}
 
-	// Debug: Log FFT magnitudes
-	if len(leftFFT.coeffs) > 500 {
-		LogDebug("FFT: L_coeffs=%d, L_mag[100]=%.8f, R_mag[100]=%.8f",
-			len(leftFFT.coeffs), cmplx.Abs(leftFFT.coeffs[100]), cmplx.Abs(rightFFT.coeffs[100]))
-	}
-
-	binWidth := float64(ap.sampleRate) / float64(len(leftChannel))
+	// fourier.FFT keeps scratch space, so the channels run one after the other
+	ap.fft.Coefficients(ap.coeffsLeft, ap.windowedLeft)
+	ap.fft.Coefficients(ap.coeffsRight, ap.windowedRight)
 
 	// Extract energy from bands using BOTH channels
-	var bandEnergies [9]float64
+	bandEnergies := ap.energies
 	var totalEnergy float64
 
-	// Use package-level `frequencyBands` (defined in main.go) for frequency ranges.
-	for i := range bandEnergies {
-		if i >= len(frequencyBands) {
-			break
-		}
-		fb := frequencyBands[i]
-		minBin := int(fb.MinFreq / binWidth)
-		maxBin := int(fb.MaxFreq / binWidth)
-
-		// clamp and validate
-		if minBin < 0 {
-			minBin = 0
-		}
-		if maxBin > len(leftFFT.coeffs) {
-			maxBin = len(leftFFT.coeffs)
-		}
-		if maxBin <= minBin {
-			bandEnergies[i] = 0
-			continue
-		}
-
-		// Process LEFT and RIGHT channels separately, then combine
-		var leftBandSum, rightBandSum float64
-
-		for j := minBin; j < maxBin && j < len(leftFFT.coeffs); j++ {
-			// Left channel
-			leftMag := cmplx.Abs(leftFFT.coeffs[j])
-			weightedLeft := leftMag * math.Log1p(leftMag)
-			leftBandSum += weightedLeft * weightedLeft
-
-			// Right channel
-			rightMag := cmplx.Abs(rightFFT.coeffs[j])
-			weightedRight := rightMag * math.Log1p(rightMag)
-			rightBandSum += weightedRight * weightedRight
-		}
-
-		denom := float64(maxBin - minBin)
-		if denom <= 0 {
-			bandEnergies[i] = 0
-			continue
-		}
+	// Process LEFT and RIGHT channels separately, then combine
+	ap.analyzer.Analyze(ap.coeffsLeft, ap.historyLeft, ap.levelsLeft)
+	ap.analyzer.Analyze(ap.coeffsRight, ap.historyRight, ap.levelsRight)
 
+	for i := range bandEnergies {
 		// Combine L+R with stereo width calculation
 		// Use RMS of both channels plus a stereo width factor
-		leftEnergy := math.Sqrt(leftBandSum / denom)
-		rightEnergy := math.Sqrt(rightBandSum / denom)
+		leftEnergy := ap.levelsLeft[i]
+		rightEnergy := ap.levelsRight[i]
+
+		// Per-channel levels on the same scale as the combined one
+		ap.bandsLeft[i] = channelBandLevel(leftEnergy, ap.scaleFactors[i])
+		ap.bandsRight[i] = channelBandLevel(rightEnergy, ap.scaleFactors[i])
 
 		// Stereo width: difference between L and R adds variation
 		stereoWidth := math.Abs(leftEnergy-rightEnergy) * 0.3
@@ -181,39 +351,24 @@ func (ap *AudioProcessor) ProcessBuffer(buffer []float32) AudioFrame {
 
 		// Apply power curve to enhance mid-range response
 		bandEnergy = math.Pow(bandEnergy, 0.8)
-
-		// Per-band scaling to compensate for natural frequency roll-off
-		// Balanced for "Liquid" movement: responsive but not jittery
-		scaleFactors := []float64{
-			10.0, // Sub-Bass (Thump)
-			12.0, // Bass
-			14.0, // Low Mids
-			16.0, // Low-Mid
-			18.0, // Mids
-			22.0, // Upper Mids
-			26.0, // Presence
-			30.0, // Highs
-			35.0, // Air
-		}
-
-		if i < len(scaleFactors) {
-			bandEnergy *= scaleFactors[i]
-		} else {
-			bandEnergy *= 100000.0
-		}
-
-		LogDebug("Band %d: L=%.8f, R=%.8f, combined=%.6f, stereoWidth=%.6f (bins %d-%d)",
-			i, leftEnergy, rightEnergy, bandEnergy, stereoWidth, minBin, maxBin)
+		bandEnergy *= ap.scaleFactors[i]
 
 		// Ensure no NaN or Inf
 		if !math.IsNaN(bandEnergy) && !math.IsInf(bandEnergy, 0) && bandEnergy > 0 {
 			bandEnergies[i] = bandEnergy
-			totalEnergy += bandEnergy
 		} else {
 			bandEnergies[i] = 0
 		}
 	}
 
+	// Automatic gain and --sensitivity, before anything gets clamped
+	gain := ap.agc.Apply(bandEnergies)
+	for i, e := range bandEnergies {
+		totalEnergy += e
+		ap.bandsLeft[i] = math.Min(ap.bandsLeft[i]*ap.agc.Gain(i), 1)
+		ap.bandsRight[i] = math.Min(ap.bandsRight[i]*ap.agc.Gain(i), 1)
+	}
+
 	// Gentler normalization - preserve relative differences between bands
 	// Instead of forcing max to 1.0, just clamp outliers
 	for i := range bandEnergies {
@@ -226,41 +381,96 @@ func (ap *AudioProcessor) ProcessBuffer(buffer []float32) AudioFrame {
 		}
 	}
 
-	LogDebug("Energy stats: total=%.6f, bands=[%.3f,%.3f,%.3f,%.3f,%.3f,%.3f,%.3f,%.3f,%.3f]",
-		totalEnergy, bandEnergies[0], bandEnergies[1], bandEnergies[2],
-		bandEnergies[3], bandEnergies[4], bandEnergies[5], bandEnergies[6], bandEnergies[7], bandEnergies[8])
-
-	chaosLevel := calculateChaos(bandEnergies[:], totalEnergy)
+	beat, onsetStrength := ap.onsets.Process(ap.coeffsLeft, ap.coeffsRight, ap.bandOnsets)
+	bpm, tempoConfidence := ap.tempo.Add(ap.onsets.Flux())
+	features := ap.spectralFeatures(ap.onsets.Flux())
+	buf.spectrum = ap.logSpectrum(buf.spectrum[:0])
+	chaosLevel := calculateChaos(bandEnergies, totalEnergy)
+	if ap.chaosMap != nil {
+		chaosLevel = ap.chaosMap.Chaos(features)
+	}
+	stereo := ap.stereoImage(buf)
+	waveform := ap.waveform(buf)
+	chroma, key, keyConfidence := ap.chroma.Process(ap.coeffsLeft, ap.coeffsRight)
+	pitch, pitchClarity := ap.detectPitch()
+	idle := ap.silence.Update(features.RMS)
 
 	// attach metadata if available
 	var metadata AudioMetadata
-	if ap.mediaProvider != nil {
+	if ap.metadataSource != nil {
+		metadata = ap.metadataSource.CurrentMetadata()
+	} else if ap.mediaProvider != nil {
 		metadata = ap.mediaProvider.GetCurrentMedia()
 	} else {
 		metadata = DefaultMetadata()
 	}
 
+	// The frame outlives this analysis
+	buf.bands = append(buf.bands[:0], bandEnergies...)
+	buf.bandOnsets = append(buf.bandOnsets[:0], ap.bandOnsets...)
+
 	return AudioFrame{
-		Bands:      bandEnergies,
-		ChaosLevel: chaosLevel,
-		Timestamp:  time.Now(),
-		Metadata:   metadata,
+		Bands:           buf.bands,
+		ChaosLevel:      chaosLevel,
+		Features:        features,
+		Beat:            beat,
+		OnsetStrength:   onsetStrength,
+		BandOnsets:      buf.bandOnsets,
+		BPM:             bpm,
+		TempoConfidence: tempoConfidence,
+		Loudness:        ap.loudness.Reading(),
+		Gain:            gain,
+		Chroma:          chroma,
+		Key:             key,
+		KeyConfidence:   keyConfidence,
+		Pitch:           pitch,
+		PitchClarity:    pitchClarity,
+		Idle:            idle,
+		Stereo:          stereo,
+		Waveform:        waveform,
+		Spectrum:        buf.spectrum,
+		Timestamp:       time.Now(),
+		Metadata:        metadata,
 	}
 }
 
+// detectPitch runs the pitch detector on the mono mix of the ring
+func (ap *AudioProcessor) detectPitch() (float64, float64) {
+	if ap.sincePitch++; ap.sincePitch < ap.pitchEvery {
+		return ap.lastPitch, ap.lastClarity
+	}
+	ap.sincePitch = 0
+
+	ringSize := len(ap.ringLeft)
+	start := (ap.ringPos - len(ap.historyMono) + ringSize) % ringSize
+	for i := range ap.historyMono {
+		pos := (start + i) % ringSize
+		ap.historyMono[i] = (ap.ringLeft[pos] + ap.ringRight[pos]) / 2
+	}
+	ap.lastPitch, ap.lastClarity = ap.pitch.Process(ap.historyMono)
+	return ap.lastPitch, ap.lastClarity
+}
+
+// channelBandLevel scales one channel's band RMS like the combined bands, without
+// the quiet-band boost so L and R stay comparable. Gain and clamping follow
+// after the AGC.
+func channelBandLevel(energy, scale float64) float64 {
+	level := math.Pow(energy, 0.8) * scale
+	if math.IsNaN(level) || math.IsInf(level, 0) || level <= 0 {
+		return 0
+	}
+	return level
+}
+
 func calculateChaos(energies []float64, total float64) float64 {
 	if total < 0.0001 {
 		return 0.0
 	}
-	normalized := make([]float64, len(energies))
-	for i, e := range energies {
-		normalized[i] = e / total
-	}
-	// calculate variance
+	// calculate variance of the normalized energies
 	mean := 1.0 / float64(len(energies))
 	variance := 0.0
-	for _, n := range normalized {
-		diff := n - mean
+	for _, e := range energies {
+		diff := e/total - mean
 		variance += diff * diff
 	}
 
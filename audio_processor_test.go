@@ -0,0 +1,277 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+const testSampleRate = 44100
+
+// fixedMetadata keeps the tests off the desktop media session
+type fixedMetadata struct{}
+
+func (fixedMetadata) CurrentMetadata() AudioMetadata { return DefaultMetadata() }
+
+func newTestProcessor(t *testing.T, analysis AnalysisConfig) *AudioProcessor {
+	t.Helper()
+	if analysis.FFTSize == 0 {
+		analysis.FFTSize = 4096
+	}
+	if analysis.HopSize == 0 {
+		analysis.HopSize = 512
+	}
+	if analysis.Window == "" {
+		analysis.Window = "hann"
+	}
+	analysis.AGC = AGCConfig{Mode: "off", Sensitivity: 1}
+
+	ap, err := NewAudioProcessor(CaptureFormat{SampleRate: testSampleRate, Channels: 2}, analysis)
+	if err != nil {
+		t.Fatalf("NewAudioProcessor: %v", err)
+	}
+	ap.SetMetadataSource(fixedMetadata{})
+	return ap
+}
+
+// sine fills stereo buffers with a tone of the given peak amplitude
+func sine(freq, amplitude float64) func([]float32) {
+	sample := 0
+	return func(buf []float32) {
+		for i := 0; i+1 < len(buf); i += 2 {
+			v := float32(amplitude * math.Sin(2*math.Pi*freq*float64(sample)/testSampleRate))
+			buf[i], buf[i+1] = v, v
+			sample++
+		}
+	}
+}
+
+// generated fills stereo buffers from the synthetic backend's generator
+func generated(t *testing.T, kind string, bpm float64) func([]float32) {
+	t.Helper()
+	sg, err := NewSignalGenerator(kind, testSampleRate, 2, bpm)
+	if err != nil {
+		t.Fatalf("NewSignalGenerator: %v", err)
+	}
+	return sg.Fill
+}
+
+// run feeds seconds of audio through the processor in 1024 frame buffers
+// and returns every frame it emitted
+func run(ap *AudioProcessor, fill func([]float32), seconds float64) []AudioFrame {
+	var frames []AudioFrame
+	buf := make([]float32, 2*1024)
+	for range int(seconds * testSampleRate / 1024) {
+		fill(buf)
+		ap.ProcessBuffer(buf, func(frame AudioFrame) { frames = append(frames, frame) })
+	}
+	return frames
+}
+
+func loudestBand(bands []float64) int {
+	loudest := 0
+	for i, e := range bands {
+		if e > bands[loudest] {
+			loudest = i
+		}
+	}
+	return loudest
+}
+
+// Frames come from a ring of buffers, so once every buffer has been used
+// analysis doesn't allocate
+func TestProcessBufferAllocs(t *testing.T) {
+	for _, analyzer := range analyzerNames {
+		ap := newTestProcessor(t, AnalysisConfig{Analyzer: analyzer})
+		fill := generated(t, "pink", 120)
+		run(ap, fill, 1)
+
+		buf := make([]float32, 2*1024)
+		fill(buf)
+		allocs := testing.AllocsPerRun(100, func() {
+			ap.ProcessBuffer(buf, func(AudioFrame) {})
+		})
+		if allocs != 0 {
+			t.Errorf("%s analyzer: %.1f allocations per buffer", analyzer, allocs)
+		}
+	}
+}
+
+func TestNewAudioProcessorConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		analysis AnalysisConfig
+	}{
+		{"FFT too small", AnalysisConfig{FFTSize: 32, HopSize: 16, Window: "hann"}},
+		{"zero hop", AnalysisConfig{FFTSize: 2048, HopSize: 0, Window: "hann"}},
+		{"hop over FFT size", AnalysisConfig{FFTSize: 2048, HopSize: 4096, Window: "hann"}},
+		{"unknown window", AnalysisConfig{FFTSize: 2048, HopSize: 512, Window: "kaiser"}},
+		{"unknown analyzer", AnalysisConfig{FFTSize: 2048, HopSize: 512, Window: "hann", Analyzer: "wavelet"}},
+		{"unknown chaos feature", AnalysisConfig{FFTSize: 2048, HopSize: 512, Window: "hann", Chaos: "loudness"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.analysis.AGC = AGCConfig{Mode: "off", Sensitivity: 1}
+			if _, err := NewAudioProcessor(CaptureFormat{SampleRate: testSampleRate, Channels: 2}, tt.analysis); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+// Every window and hop gives one frame per hop, and all of them put a tone
+// in its band
+func TestProcessBufferWindowsAndHops(t *testing.T) {
+	const seconds = 1.0
+	samples := int(seconds*testSampleRate) / 1024 * 1024
+	for _, window := range analysisWindows {
+		for _, hop := range []int{256, 512, 1024, 2048} {
+			ap := newTestProcessor(t, AnalysisConfig{FFTSize: 2048, HopSize: hop, Window: window})
+			frames := run(ap, sine(1400, 0.05), seconds)
+
+			if len(frames) != samples/hop {
+				t.Errorf("%s window, hop %d: got %d frames, want %d", window, hop, len(frames), samples/hop)
+				continue
+			}
+			if band := loudestBand(frames[len(frames)-1].Bands); frequencyBands[band].Name != "Mids" {
+				t.Errorf("%s window, hop %d: 1.4kHz is loudest in %s", window, hop, frequencyBands[band].Name)
+			}
+		}
+	}
+}
+
+func TestSineBand(t *testing.T) {
+	tests := []struct {
+		freq float64
+		band string
+	}{
+		{35, "Sub-Bass"},
+		{120, "Bass"},
+		{350, "Low Mids"},
+		{700, "Low-Mid"},
+		{1400, "Mids"},
+		{2800, "Upper Mids"},
+		{5000, "Presence"},
+		{8500, "Highs"},
+		{15000, "Air"},
+	}
+	for _, tt := range tests {
+		ap := newTestProcessor(t, AnalysisConfig{})
+		frames := run(ap, sine(tt.freq, 0.05), 1)
+		if band := frequencyBands[loudestBand(frames[len(frames)-1].Bands)].Name; band != tt.band {
+			t.Errorf("%gHz is loudest in %s, want %s", tt.freq, band, tt.band)
+		}
+	}
+}
+
+func TestBandLayouts(t *testing.T) {
+	for _, layout := range []string{"log", "octave", "third-octave", "mel"} {
+		bands, err := BuildBands(layout, 0, nil, testSampleRate)
+		if err != nil {
+			t.Fatalf("%s: %v", layout, err)
+		}
+		ap := newTestProcessor(t, AnalysisConfig{Bands: bands})
+		frames := run(ap, sine(1000, 0.05), 1)
+
+		band := bands[loudestBand(frames[len(frames)-1].Bands)]
+		if band.MinFreq > 1000*1.1 || band.MaxFreq < 1000/1.1 {
+			t.Errorf("%s: 1kHz is loudest in %s (%.0f-%.0fHz)", layout, band.Name, band.MinFreq, band.MaxFreq)
+		}
+	}
+}
+
+func TestSinePitch(t *testing.T) {
+	for _, freq := range []float64{41.2, 110, 261.63, 440, 1000, 1760} {
+		ap := newTestProcessor(t, AnalysisConfig{})
+		frames := run(ap, sine(freq, 0.1), 0.5)
+		last := frames[len(frames)-1]
+		if math.Abs(last.Pitch-freq)/freq > 0.005 {
+			t.Errorf("pitch of %gHz: got %.2fHz", freq, last.Pitch)
+		}
+		if last.PitchClarity < 0.9 {
+			t.Errorf("pitch of %gHz: clarity %.2f", freq, last.PitchClarity)
+		}
+	}
+}
+
+func TestPitchSilence(t *testing.T) {
+	ap := newTestProcessor(t, AnalysisConfig{})
+	frames := run(ap, generated(t, "silence", 120), 0.5)
+	if pitch := frames[len(frames)-1].Pitch; pitch != 0 {
+		t.Errorf("silence has a pitch of %.2fHz", pitch)
+	}
+}
+
+func TestChordKey(t *testing.T) {
+	ap := newTestProcessor(t, AnalysisConfig{})
+	frames := run(ap, generated(t, "chord", 120), 10)
+	last := frames[len(frames)-1]
+	if want := (MusicalKey{Tonic: 9, Minor: true}); last.Key != want {
+		t.Errorf("the A minor chord gives %s, want %s", last.Key, want)
+	}
+	if last.Key.Camelot() != "8A" {
+		t.Errorf("A minor is %s on the Camelot wheel, want 8A", last.Key.Camelot())
+	}
+}
+
+func TestClickTempo(t *testing.T) {
+	const seconds = 12.0
+	for _, bpm := range []float64{70, 90, 120, 128, 150, 160} {
+		ap := newTestProcessor(t, AnalysisConfig{FFTSize: 2048})
+		frames := run(ap, generated(t, "clicks", bpm), seconds)
+
+		last := frames[len(frames)-1]
+		if math.Abs(last.BPM-bpm) > 1 {
+			t.Errorf("%g BPM click track: got %.2f BPM", bpm, last.BPM)
+		}
+		if last.TempoConfidence < 0.5 {
+			t.Errorf("%g BPM click track: confidence %.2f", bpm, last.TempoConfidence)
+		}
+
+		// Every click past the first couple of seconds, while the onset
+		// threshold settles, is a beat, and nothing else is
+		var beats int
+		settled := int(2 * testSampleRate / 512)
+		for _, frame := range frames[settled:] {
+			if frame.Beat {
+				beats++
+			}
+		}
+		want := (seconds - 2) * bpm / 60
+		if math.Abs(float64(beats)-want) > 1 {
+			t.Errorf("%g BPM click track: %d beats after 2s, want %.0f", bpm, beats, want)
+		}
+	}
+}
+
+func TestSteadyToneHasNoBeats(t *testing.T) {
+	ap := newTestProcessor(t, AnalysisConfig{})
+	frames := run(ap, sine(440, 0.1), 4)
+	for i, frame := range frames[10:] {
+		if frame.Beat {
+			t.Fatalf("beat in a steady tone at frame %d, onset strength %.2f", i+10, frame.OnsetStrength)
+		}
+	}
+}
+
+func TestToneLoudness(t *testing.T) {
+	for _, dbfs := range []float64{-10, -20, -30} {
+		ap := newTestProcessor(t, AnalysisConfig{})
+		frames := run(ap, sine(1000, math.Pow(10, dbfs/20)), 4)
+
+		loudness := frames[len(frames)-1].Loudness
+		// A 1kHz sine on both channels reads its peak level in LUFS
+		for _, reading := range []struct {
+			name  string
+			value float64
+		}{
+			{"momentary", loudness.Momentary},
+			{"short-term", loudness.ShortTerm},
+			{"integrated", loudness.Integrated},
+			{"true peak", loudness.TruePeak},
+		} {
+			if math.Abs(reading.value-dbfs) > 0.3 {
+				t.Errorf("%g dBFS tone: %s %.2f", dbfs, reading.name, reading.value)
+			}
+		}
+	}
+}
@@ -0,0 +1,225 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// sinkInput is one application playback stream as reported by
+// `pactl list sink-inputs`
+type sinkInput struct {
+	Index  int
+	App    string // application.name
+	Binary string // application.process.binary
+	Corked bool   // paused by the application
+}
+
+func (pb *parecBackend) CaptureApp() string {
+	pb.appMu.Lock()
+	defer pb.appMu.Unlock()
+	return pb.app
+}
+
+// SetCaptureApp switches the capture target; the supervisor restarts parec
+func (pb *parecBackend) SetCaptureApp(name string) {
+	pb.appMu.Lock()
+	changed := pb.app != name
+	pb.app = name
+	pb.appMu.Unlock()
+
+	if changed {
+		LogInfo("Capture app set to %q", name)
+		select {
+		case pb.retarget <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// CaptureApps lists applications that currently have a playback stream
+func (pb *parecBackend) CaptureApps() []string {
+	inputs, err := listSinkInputs()
+	if err != nil {
+		LogError("Listing sink-inputs failed: %v", err)
+		return nil
+	}
+
+	seen := map[string]bool{}
+	var apps []string
+	for _, input := range inputs {
+		name := input.App
+		if name == "" {
+			name = input.Binary
+		}
+		if name != "" && !seen[strings.ToLower(name)] {
+			seen[strings.ToLower(name)] = true
+			apps = append(apps, name)
+		}
+	}
+	sort.Strings(apps)
+	return apps
+}
+
+// resolveAppTarget finds the sink-input belonging to app. With
+// appFollowPlayer the name comes from the MPRIS player that is playing.
+func (pb *parecBackend) resolveAppTarget(app string) (parecTarget, error) {
+	if app == appFollowPlayer {
+		player, err := pb.playingApp()
+		if err != nil {
+			return parecTarget{}, err
+		}
+		app = player
+	}
+
+	input, err := findSinkInput(app)
+	if err != nil {
+		return parecTarget{}, err
+	}
+	return parecTarget{
+		stream:    input.Index,
+		hasStream: true,
+		label:     fmt.Sprintf("%s (sink-input #%d)", app, input.Index),
+	}, nil
+}
+
+func (pb *parecBackend) playingApp() (string, error) {
+	if pb.media == nil {
+		media, err := NewMediaSessionProvider()
+		if err != nil {
+			return "", err
+		}
+		pb.media = media
+	}
+
+	current := pb.media.GetCurrentMedia()
+	if !current.IsPlaying {
+		return "", fmt.Errorf("no MPRIS player is playing")
+	}
+	return current.AppName, nil
+}
+
+// findSinkInput matches app case-insensitively against the application and
+// binary names (for appFollowPlayer, app is the MPRIS identity), preferring
+// streams that aren't corked. An exact name wins; otherwise app may be the
+// start of a name, as long as only one application starts that way.
+func findSinkInput(app string) (sinkInput, error) {
+	inputs, err := listSinkInputs()
+	if err != nil {
+		return sinkInput{}, err
+	}
+	return matchSinkInput(inputs, app)
+}
+
+func matchSinkInput(inputs []sinkInput, app string) (sinkInput, error) {
+	want := strings.ToLower(app)
+	if want == "" {
+		return sinkInput{}, fmt.Errorf("no application given")
+	}
+	exact := func(input sinkInput) bool {
+		return strings.ToLower(input.App) == want || strings.ToLower(input.Binary) == want
+	}
+	prefix := func(input sinkInput) bool {
+		return strings.HasPrefix(strings.ToLower(input.App), want) || strings.HasPrefix(strings.ToLower(input.Binary), want)
+	}
+
+	found := pickSinkInput(inputs, exact)
+	if found < 0 {
+		// Several streams of one application are fine, several applications aren't
+		var apps []string
+		for _, input := range inputs {
+			if name := sinkInputName(input); prefix(input) && !slices.Contains(apps, name) {
+				apps = append(apps, name)
+			}
+		}
+		if len(apps) > 1 {
+			sort.Strings(apps)
+			return sinkInput{}, fmt.Errorf("%q matches several applications: %s", app, strings.Join(apps, ", "))
+		}
+		found = pickSinkInput(inputs, prefix)
+	}
+	if found < 0 {
+		return sinkInput{}, fmt.Errorf("no audio stream from %s", app)
+	}
+	return inputs[found], nil
+}
+
+// pickSinkInput returns the index of the first matching input that isn't
+// corked, or failing that the first matching one, -1 if none match
+func pickSinkInput(inputs []sinkInput, matches func(sinkInput) bool) int {
+	found := -1
+	for i, input := range inputs {
+		if !matches(input) {
+			continue
+		}
+		if found < 0 || (inputs[found].Corked && !input.Corked) {
+			found = i
+		}
+	}
+	return found
+}
+
+// sinkInputName is the name an application's streams are listed under
+func sinkInputName(input sinkInput) string {
+	name := input.App
+	if name == "" {
+		name = input.Binary
+	}
+	return strings.ToLower(name)
+}
+
+// listSinkInputs parses the long form of `pactl list sink-inputs`:
+//
+//	Sink Input #42
+//		Corked: no
+//		Properties:
+//			application.name = "Spotify"
+//			application.process.binary = "spotify"
+func listSinkInputs() ([]sinkInput, error) {
+	output, err := pactlCommand("list", "sink-inputs").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run pactl: %w", err)
+	}
+
+	var inputs []sinkInput
+	var current *sinkInput
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if rest, ok := strings.CutPrefix(line, "Sink Input #"); ok {
+			index, err := strconv.Atoi(rest)
+			if err != nil {
+				current = nil
+				continue
+			}
+			inputs = append(inputs, sinkInput{Index: index})
+			current = &inputs[len(inputs)-1]
+			continue
+		}
+		if current == nil {
+			continue
+		}
+
+		if value, ok := strings.CutPrefix(line, "Corked:"); ok {
+			current.Corked = strings.TrimSpace(value) == "yes"
+		} else if key, value, ok := strings.Cut(line, " = "); ok {
+			value = strings.Trim(value, `"`)
+			switch key {
+			case "application.name":
+				current.App = value
+			case "application.process.binary":
+				current.Binary = value
+			}
+		}
+	}
+	return inputs, nil
+}
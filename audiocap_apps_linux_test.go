@@ -0,0 +1,46 @@
+//go:build linux
+// +build linux
+
+package main
+
+import "testing"
+
+func TestMatchSinkInput(t *testing.T) {
+	inputs := []sinkInput{
+		{Index: 1, App: "Firefox", Binary: "firefox", Corked: true},
+		{Index: 2, App: "Firefox", Binary: "firefox"},
+		{Index: 3, App: "Spotify", Binary: "spotify"},
+		{Index: 4, App: "mpv Media Player", Binary: "mpv"},
+		{Index: 5, App: "", Binary: "mpd"},
+		{Index: 6, App: "Chromium", Binary: "chromium-browser"},
+	}
+	tests := []struct {
+		app  string
+		want int // sink-input index, 0 for an error
+	}{
+		{"spotify", 3},
+		{"SPOTIFY", 3},
+		{"firefox", 2}, // the stream that isn't corked
+		{"mpv", 4},     // binary, exactly, though "mpv Media Player" would do as a prefix too
+		{"mpd", 5},
+		{"Chromium", 6},
+		{"spot", 3},
+		{"chromium-b", 6},
+		{"mp", 0},  // mpv and mpd
+		{"fox", 0}, // substrings don't count
+		{"spotify-client", 0},
+		{"vlc", 0},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		input, err := matchSinkInput(inputs, tt.app)
+		switch {
+		case tt.want == 0 && err == nil:
+			t.Errorf("matchSinkInput(%q) = #%d, want an error", tt.app, input.Index)
+		case tt.want != 0 && err != nil:
+			t.Errorf("matchSinkInput(%q): %v", tt.app, err)
+		case input.Index != tt.want && tt.want != 0:
+			t.Errorf("matchSinkInput(%q) = #%d, want #%d", tt.app, input.Index, tt.want)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gordonklaus/portaudio"
+)
+
+func init() {
+	RegisterCaptureBackend("file", newFileBackend)
+}
+
+// PlaybackControl is implemented by backends that play back recorded
+// material and can be paused or seeked from the TUI
+type PlaybackControl interface {
+	TogglePause() bool
+	Paused() bool
+	Seek(offset time.Duration)
+	Position() (pos time.Duration, total time.Duration)
+}
+
+// fileBackend decodes a WAV or FLAC file and plays it through the default
+// PortAudio output while feeding the visualizer at the same pace. With no
+// usable output device (or --mute) it paces itself against the wall clock.
+type fileBackend struct {
+	path    string
+	dec     audioDecoder
+	format  CaptureFormat
+	mute    bool
+	paused  atomic.Bool
+	pos     atomic.Int64 // frames
+	seekMu  sync.Mutex
+	seekTo  int64
+	hasSeek bool
+}
+
+func newFileBackend(cfg CaptureConfig) (CaptureBackend, error) {
+	if cfg.Path == "" {
+		return nil, errors.New("no file given (use --file)")
+	}
+
+	dec, err := openAudioDecoder(cfg.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fileBackend{
+		path: cfg.Path,
+		dec:  dec,
+		mute: cfg.Mute,
+		format: CaptureFormat{
+			SampleRate:      dec.SampleRate(),
+			Channels:        dec.Channels(),
+			FramesPerBuffer: cfg.FramesPerBuffer,
+			Encoding:        dec.Encoding(),
+		},
+	}, nil
+}
+
+func (fb *fileBackend) Name() string { return "file" }
+
+func (fb *fileBackend) Format() CaptureFormat { return fb.format }
+
+func (fb *fileBackend) Start() (chan []float32, func() error, error) {
+	frames, channels := fb.format.FramesPerBuffer, fb.format.Channels
+	outBuf := make([]float32, frames*channels)
+
+	var stream *portaudio.Stream
+	if !fb.mute {
+		var err error
+		stream, err = portaudio.OpenDefaultStream(0, channels, float64(fb.format.SampleRate), frames, outBuf)
+		if err == nil {
+			err = stream.Start()
+			if err != nil {
+				stream.Close()
+			}
+		}
+		if err != nil {
+			LogError("File playback: no audio output (%v), visualizing silently", err)
+			stream = nil
+		}
+	}
+
+	LogInfo("Playing %s (%s, %s)", filepath.Base(fb.path), fb.format, fb.duration(fb.dec.TotalFrames()))
+
+	ch := make(chan []float32, 8)
+	done := make(chan struct{})
+	finished := make(chan struct{})
+
+	go func() {
+		defer func() {
+			if stream != nil {
+				stream.Stop()
+				stream.Close()
+			}
+			fb.dec.Close()
+			close(ch)
+			close(finished)
+		}()
+
+		bufDuration := fb.duration(int64(frames))
+		next := time.Now()
+
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+
+			fb.applySeek()
+
+			buf := make([]float32, frames*channels)
+			if !fb.paused.Load() {
+				n, err := fb.dec.ReadFrames(buf)
+				if err != nil && err != io.EOF {
+					LogError("File decode error: %v", err)
+					return
+				}
+				if n == 0 {
+					LogInfo("Reached end of %s", filepath.Base(fb.path))
+					return
+				}
+				fb.pos.Add(int64(n))
+			}
+			// A paused file keeps sending silence so the display settles
+
+			if stream != nil {
+				copy(outBuf, buf)
+				if err := stream.Write(); err != nil {
+					LogError("Playback write error: %v", err)
+				}
+			} else {
+				next = next.Add(bufDuration)
+				if wait := time.Until(next); wait > 0 {
+					time.Sleep(wait)
+				} else if wait < -time.Second {
+					// Fell far behind (suspend, debugger), don't try to catch up
+					next = time.Now()
+				}
+			}
+
+			select {
+			case ch <- buf:
+			default:
+				// Channel full, skip this buffer
+			}
+		}
+	}()
+
+	stop := func() error {
+		close(done)
+		<-finished
+		return nil
+	}
+
+	return ch, stop, nil
+}
+
+func (fb *fileBackend) applySeek() {
+	fb.seekMu.Lock()
+	target, ok := fb.seekTo, fb.hasSeek
+	fb.hasSeek = false
+	fb.seekMu.Unlock()
+
+	if !ok {
+		return
+	}
+	if err := fb.dec.SeekFrame(target); err != nil {
+		LogError("Seek failed: %v", err)
+		return
+	}
+	fb.pos.Store(fb.clampFrame(target))
+}
+
+// clampFrame keeps a position within the file. FLAC streams may not state
+// their length, TotalFrames is 0 then and only the start is a bound.
+func (fb *fileBackend) clampFrame(frame int64) int64 {
+	if total := fb.dec.TotalFrames(); total > 0 {
+		frame = min(frame, total)
+	}
+	return max(0, frame)
+}
+
+func (fb *fileBackend) duration(frames int64) time.Duration {
+	return time.Duration(frames) * time.Second / time.Duration(fb.format.SampleRate)
+}
+
+func (fb *fileBackend) TogglePause() bool {
+	paused := !fb.paused.Load()
+	fb.paused.Store(paused)
+	return paused
+}
+
+func (fb *fileBackend) Paused() bool {
+	return fb.paused.Load()
+}
+
+// Seek moves playback relative to the current position. The decoder is
+// only touched from the playback goroutine, so this just queues a target.
+func (fb *fileBackend) Seek(offset time.Duration) {
+	delta := int64(offset.Seconds() * float64(fb.format.SampleRate))
+
+	fb.seekMu.Lock()
+	defer fb.seekMu.Unlock()
+	base := fb.pos.Load()
+	if fb.hasSeek {
+		base = fb.seekTo
+	}
+	fb.seekTo = fb.clampFrame(base + delta)
+	fb.hasSeek = true
+}
+
+func (fb *fileBackend) Position() (time.Duration, time.Duration) {
+	return fb.duration(fb.pos.Load()), fb.duration(fb.dec.TotalFrames())
+}
+
+// formatPlaybackTime renders a duration as m:ss
+func formatPlaybackTime(d time.Duration) string {
+	secs := int(d.Seconds())
+	return fmt.Sprintf("%d:%02d", secs/60, secs%60)
+}
@@ -9,27 +9,129 @@ import (
 	"io"
 	"log"
 	"os/exec"
+	"strconv"
 	"strings"
+	"sync"
 	"unsafe"
 )
 
-// StartAudioCapture starts capturing system audio on Linux using PulseAudio/PipeWire
-func StartAudioCapture(sampleRate, bufferSize int) (chan []float32, func() error, error) {
-	// Get the default sink (playback device)
-	defaultSink, err := getDefaultSink()
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to get default sink: %w", err)
+func init() {
+	RegisterCaptureBackend("parec", newParecBackend)
+	RegisterCaptureDevices("parec", listPulseSources)
+}
+
+// parecBackend captures the monitor of the default sink through the parec
+// subprocess that ships with PulseAudio and pipewire-pulse
+type parecBackend struct {
+	device string // explicit source, empty = monitor of the default sink
+	format CaptureFormat
+	status chan CaptureStatus
+
+	appMu    sync.Mutex
+	app      string        // capture only this application's stream, see audiocap_apps_linux.go
+	retarget chan struct{} // poked when app changes at runtime
+	media    *MediaSessionProvider
+}
+
+func newParecBackend(cfg CaptureConfig) (CaptureBackend, error) {
+	if _, err := exec.LookPath("parec"); err != nil {
+		return nil, fmt.Errorf("parec not found in PATH: %w", err)
 	}
 
-	monitorSource := defaultSink + ".monitor"
-	log.Printf("Capturing from monitor source: %s", monitorSource)
+	if cfg.Device != "" {
+		if err := checkPulseSource(cfg.Device); err != nil {
+			return nil, err
+		}
+	}
+
+	channels := cfg.Channels
+	if channels == 0 {
+		channels = 2
+	}
+
+	return &parecBackend{
+		device:   cfg.Device,
+		app:      cfg.App,
+		retarget: make(chan struct{}, 1),
+		format: CaptureFormat{
+			SampleRate:      cfg.SampleRate,
+			Channels:        channels,
+			FramesPerBuffer: cfg.FramesPerBuffer,
+			Encoding:        SampleFormatF32LE,
+		},
+		status: make(chan CaptureStatus, 4),
+	}, nil
+}
+
+func (pb *parecBackend) Name() string { return "parec" }
+
+func (pb *parecBackend) Format() CaptureFormat { return pb.format }
+
+func (pb *parecBackend) Status() <-chan CaptureStatus { return pb.status }
+
+// Start runs parec under a supervisor (see audiocap_supervisor_linux.go),
+// so the returned channel survives sink switches and parec crashes
+func (pb *parecBackend) Start() (chan []float32, func() error, error) {
+	out := make(chan []float32, 8)
+	done := make(chan struct{})
+	finished := make(chan struct{})
+
+	go func() {
+		defer func() {
+			close(out)
+			close(finished)
+		}()
+		pb.supervise(out, done)
+	}()
+
+	stop := func() error {
+		close(done)
+		<-finished
+		if pb.media != nil {
+			pb.media.Close()
+		}
+		return nil
+	}
+
+	return out, stop, nil
+}
+
+// parecTarget is what one parec instance records: a source such as a sink
+// monitor, or a single application's sink-input when hasStream is set
+type parecTarget struct {
+	source    string
+	stream    int
+	hasStream bool
+	label     string
+}
+
+// StartAudioCapture starts capturing system audio on Linux using PulseAudio/PipeWire.
+// An empty target records the monitor of the current default sink.
+func StartAudioCapture(target parecTarget, sampleRate, bufferSize, channels int) (chan []float32, func() error, error) {
+	var targetArg string
+	if target.hasStream {
+		targetArg = fmt.Sprintf("--monitor-stream=%d", target.stream)
+		log.Printf("Capturing sink-input #%d (%s)", target.stream, target.label)
+	} else {
+		monitorSource := target.source
+		if monitorSource == "" {
+			// Get the default sink (playback device)
+			defaultSink, err := getDefaultSink()
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to get default sink: %w", err)
+			}
+			monitorSource = defaultSink + ".monitor"
+		}
+		targetArg = "--device=" + monitorSource
+		log.Printf("Capturing from source: %s", monitorSource)
+	}
 
 	// Use parec to capture audio from the monitor source
-	// Format: float32le (native endian float32), stereo (2 channels), specified sample rate
+	// Format: float32le (native endian float32), interleaved channels, specified sample rate
 	cmd := exec.Command("parec",
-		"--device="+monitorSource,
+		targetArg,
 		"--format=float32le",
-		"--channels=2",
+		fmt.Sprintf("--channels=%d", channels),
 		fmt.Sprintf("--rate=%d", sampleRate),
 		"--latency-msec=20",
 	)
@@ -43,10 +145,11 @@ func StartAudioCapture(sampleRate, bufferSize int) (chan []float32, func() error
 		return nil, nil, fmt.Errorf("failed to start parec: %w", err)
 	}
 
-	log.Printf("Successfully started parec capture: %dHz stereo (2ch), buffer=%d", sampleRate, bufferSize)
+	log.Printf("Successfully started parec capture: %dHz %dch, buffer=%d", sampleRate, channels, bufferSize)
 
 	audioChan := make(chan []float32, 8)
 	done := make(chan struct{})
+	finished := make(chan struct{})
 
 	// Reader goroutine
 	go func() {
@@ -54,9 +157,10 @@ func StartAudioCapture(sampleRate, bufferSize int) (chan []float32, func() error
 			cmd.Process.Kill()
 			cmd.Wait()
 			close(audioChan)
+			close(finished)
 		}()
 
-		buffer := make([]byte, bufferSize*2*4) // stereo: 2 channels * 4 bytes per float32
+		buffer := make([]byte, bufferSize*channels*4) // 4 bytes per float32 sample
 
 		for {
 			select {
@@ -92,6 +196,9 @@ func StartAudioCapture(sampleRate, bufferSize int) (chan []float32, func() error
 
 	stopFunc := func() error {
 		close(done)
+		// Killing parec unblocks a reader waiting on stdout
+		cmd.Process.Kill()
+		<-finished
 		return nil
 	}
 
@@ -100,7 +207,7 @@ func StartAudioCapture(sampleRate, bufferSize int) (chan []float32, func() error
 
 // getDefaultSink queries PulseAudio/PipeWire for the current default sink
 func getDefaultSink() (string, error) {
-	cmd := exec.Command("pactl", "info")
+	cmd := pactlCommand("info")
 	output, err := cmd.Output()
 	if err != nil {
 		return "", fmt.Errorf("failed to run pactl: %w", err)
@@ -119,6 +226,68 @@ func getDefaultSink() (string, error) {
 	return "", fmt.Errorf("could not find default sink")
 }
 
+// listPulseSources parses `pactl list short sources`, whose lines look like
+// "57	alsa_output.pci-0000_00_1f.3.analog-stereo.monitor	PipeWire	s32le 2ch 48000Hz	SUSPENDED"
+func listPulseSources() ([]CaptureDevice, error) {
+	output, err := pactlCommand("list", "short", "sources").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run pactl: %w", err)
+	}
+
+	defaultSource := ""
+	if info, err := pactlCommand("info").Output(); err == nil {
+		for _, line := range strings.Split(string(info), "\n") {
+			if value, ok := strings.CutPrefix(line, "Default Source:"); ok {
+				defaultSource = strings.TrimSpace(value)
+			}
+		}
+	}
+
+	var sources []CaptureDevice
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) < 4 {
+			continue
+		}
+
+		index, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+
+		source := CaptureDevice{
+			Index:   index,
+			Name:    fields[1],
+			Monitor: strings.HasSuffix(fields[1], ".monitor"),
+			Default: fields[1] == defaultSource,
+		}
+		for _, spec := range strings.Fields(fields[3]) {
+			if ch, ok := strings.CutSuffix(spec, "ch"); ok {
+				source.Channels, _ = strconv.Atoi(ch)
+			} else if hz, ok := strings.CutSuffix(spec, "Hz"); ok {
+				source.DefaultRate, _ = strconv.Atoi(hz)
+			}
+		}
+		sources = append(sources, source)
+	}
+	return sources, nil
+}
+
+// checkPulseSource makes sure --device names an existing source exactly,
+// parec would otherwise silently fall back to the default one
+func checkPulseSource(name string) error {
+	sources, err := listPulseSources()
+	if err != nil {
+		return err
+	}
+	for _, source := range sources {
+		if source.Name == name {
+			return nil
+		}
+	}
+	return fmt.Errorf("no PulseAudio/PipeWire source named %q (see `termulizer devices`)", name)
+}
+
 // float32frombits converts uint32 bits to float32
 func float32frombits(b uint32) float32 {
 	return *(*float32)(unsafe.Pointer(&b))
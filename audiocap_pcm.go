@@ -0,0 +1,321 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"syscall"
+	"time"
+)
+
+func init() {
+	RegisterCaptureBackend("stdin", newPCMStreamBackend)
+}
+
+// stdinConsumer is implemented by backends that read audio from stdin,
+// in which case the TUI has to take keyboard input from /dev/tty instead
+type stdinConsumer interface {
+	ReadsStdin() bool
+}
+
+// pcmStreamBackend reads raw interleaved PCM from stdin or a named pipe,
+// e.g. MPD's fifo audio_output or `ffmpeg -f f32le -`. A FIFO writer may
+// come and go; while it's away the backend keeps the pipeline fed with
+// silence instead of closing the channel.
+type pcmStreamBackend struct {
+	path           string // empty = stdin
+	format         CaptureFormat
+	bytesPerSample int
+}
+
+// pcmStallTimeout is how long a connected writer may go quiet (MPD paused)
+// before the backend fills in a buffer of silence
+const pcmStallTimeout = 200 * time.Millisecond
+
+func newPCMStreamBackend(cfg CaptureConfig) (CaptureBackend, error) {
+	encoding := cfg.Encoding
+	if encoding == "" {
+		encoding = SampleFormatS16LE
+	}
+
+	var bytesPerSample int
+	switch encoding {
+	case SampleFormatS16LE:
+		bytesPerSample = 2
+	case SampleFormatS32LE, SampleFormatF32LE:
+		bytesPerSample = 4
+	default:
+		return nil, fmt.Errorf("unsupported pcm format %q (want s16le, s32le or f32le)", encoding)
+	}
+
+	channels := cfg.Channels
+	if channels == 0 {
+		channels = 2
+	}
+
+	if cfg.Path != "" {
+		info, err := os.Stat(cfg.Path)
+		if err != nil {
+			return nil, fmt.Errorf("fifo: %w", err)
+		}
+		if info.Mode()&os.ModeNamedPipe == 0 {
+			return nil, fmt.Errorf("%s is not a named pipe (create one with mkfifo)", cfg.Path)
+		}
+	}
+
+	return &pcmStreamBackend{
+		path:           cfg.Path,
+		bytesPerSample: bytesPerSample,
+		format: CaptureFormat{
+			SampleRate:      cfg.SampleRate,
+			Channels:        channels,
+			FramesPerBuffer: cfg.FramesPerBuffer,
+			Encoding:        encoding,
+		},
+	}, nil
+}
+
+func (pb *pcmStreamBackend) Name() string { return "stdin" }
+
+func (pb *pcmStreamBackend) Format() CaptureFormat { return pb.format }
+
+func (pb *pcmStreamBackend) ReadsStdin() bool { return pb.path == "" }
+
+func (pb *pcmStreamBackend) Start() (chan []float32, func() error, error) {
+	ch := make(chan []float32, 8)
+	done := make(chan struct{})
+	finished := make(chan struct{})
+
+	source := "stdin"
+	if pb.path != "" {
+		source = pb.path
+	}
+	LogInfo("Reading raw PCM from %s (%s)", source, pb.format)
+
+	go func() {
+		defer func() {
+			close(ch)
+			close(finished)
+		}()
+
+		if pb.path == "" {
+			if err := pb.readStream(os.Stdin, ch, done); err != nil {
+				LogError("stdin read error: %v", err)
+			}
+			LogInfo("stdin closed, ending capture")
+			return
+		}
+
+		for {
+			f := pb.openFIFO(ch, done)
+			if f == nil {
+				return
+			}
+			LogInfo("FIFO writer connected: %s", pb.path)
+
+			err := pb.readStream(f, ch, done)
+			f.Close()
+			if err != nil {
+				LogError("FIFO read error: %v", err)
+			}
+
+			select {
+			case <-done:
+				return
+			default:
+				LogInfo("FIFO writer went away, waiting for it to come back")
+			}
+		}
+	}()
+
+	stop := func() error {
+		close(done)
+		<-finished
+		return nil
+	}
+
+	return ch, stop, nil
+}
+
+// openFIFO blocks until a writer opens the other end, sending silence so
+// the visualizer keeps running. Returns nil once done is closed.
+func (pb *pcmStreamBackend) openFIFO(ch chan []float32, done chan struct{}) *os.File {
+	type openResult struct {
+		f   *os.File
+		err error
+	}
+	opened := make(chan openResult, 1)
+	open := func() {
+		f, err := os.OpenFile(pb.path, os.O_RDONLY, 0)
+		opened <- openResult{f, err}
+	}
+	go open()
+	pending := true
+
+	ticker := time.NewTicker(pb.bufferDuration())
+	defer ticker.Stop()
+	var retry <-chan time.Time
+
+	for {
+		select {
+		case res := <-opened:
+			pending = false
+			if res.err != nil {
+				LogError("Failed to open FIFO: %v", res.err)
+				// Retry shortly, the path may be recreated by the writer
+				retry = time.After(time.Second)
+				continue
+			}
+			return res.f
+
+		case <-retry:
+			retry = nil
+			pending = true
+			go open()
+
+		case <-ticker.C:
+			pb.sendSilence(ch)
+
+		case <-done:
+			if pending {
+				// The open is stuck waiting for a writer, briefly become one to release it
+				if w, err := os.OpenFile(pb.path, os.O_WRONLY|syscall.O_NONBLOCK, 0); err == nil {
+					w.Close()
+				}
+				if res := <-opened; res.f != nil {
+					res.f.Close()
+				}
+			}
+			return nil
+		}
+	}
+}
+
+// readStream decodes buffers from r until EOF or done. Partial buffers are
+// kept across stalls so frames never get misaligned.
+func (pb *pcmStreamBackend) readStream(r *os.File, ch chan []float32, done chan struct{}) error {
+	frameBytes := pb.bytesPerSample * pb.format.Channels
+	raw := make([]byte, pb.format.FramesPerBuffer*frameBytes)
+	filled := 0
+	read := stallingReader(r, done)
+
+	for {
+		select {
+		case <-done:
+			return nil
+		default:
+		}
+
+		n, err := read(raw[filled:])
+		filled += n
+
+		if filled == len(raw) {
+			samples := make([]float32, len(raw)/pb.bytesPerSample)
+			pb.decode(raw, samples)
+			filled = 0
+
+			select {
+			case ch <- samples:
+			default:
+				// Channel full, skip this buffer
+			}
+		}
+
+		switch {
+		case err == nil:
+		case errors.Is(err, os.ErrDeadlineExceeded):
+			if filled == 0 {
+				pb.sendSilence(ch)
+			}
+		case err == io.EOF:
+			return nil
+		default:
+			return err
+		}
+	}
+}
+
+// stallingReader returns a read function that gives up with
+// os.ErrDeadlineExceeded after pcmStallTimeout without data, and with io.EOF
+// once done is closed. FIFOs support read deadlines; stdin usually doesn't,
+// so it's read on a goroutine of its own that stop can leave blocked.
+func stallingReader(r *os.File, done chan struct{}) func([]byte) (int, error) {
+	if r.SetReadDeadline(time.Now().Add(pcmStallTimeout)) == nil {
+		return func(p []byte) (int, error) {
+			r.SetReadDeadline(time.Now().Add(pcmStallTimeout))
+			return r.Read(p)
+		}
+	}
+
+	type chunk struct {
+		data []byte
+		err  error
+	}
+	chunks := make(chan chunk)
+	go func() {
+		for {
+			buf := make([]byte, 4096)
+			n, err := r.Read(buf)
+			select {
+			case chunks <- chunk{buf[:n], err}:
+			case <-done:
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	var pending chunk
+	return func(p []byte) (int, error) {
+		if len(pending.data) == 0 && pending.err == nil {
+			select {
+			case pending = <-chunks:
+			case <-time.After(pcmStallTimeout):
+				return 0, os.ErrDeadlineExceeded
+			case <-done:
+				return 0, io.EOF
+			}
+		}
+		n := copy(p, pending.data)
+		pending.data = pending.data[n:]
+		if len(pending.data) > 0 {
+			return n, nil
+		}
+		err := pending.err
+		pending.err = nil
+		return n, err
+	}
+}
+
+func (pb *pcmStreamBackend) decode(raw []byte, dst []float32) {
+	switch pb.format.Encoding {
+	case SampleFormatS16LE:
+		for i := range dst {
+			dst[i] = float32(int16(binary.LittleEndian.Uint16(raw[i*2:]))) / 32768
+		}
+	case SampleFormatS32LE:
+		for i := range dst {
+			dst[i] = float32(int32(binary.LittleEndian.Uint32(raw[i*4:]))) / 2147483648
+		}
+	case SampleFormatF32LE:
+		for i := range dst {
+			dst[i] = math.Float32frombits(binary.LittleEndian.Uint32(raw[i*4:]))
+		}
+	}
+}
+
+func (pb *pcmStreamBackend) sendSilence(ch chan []float32) {
+	select {
+	case ch <- make([]float32, pb.format.FramesPerBuffer*pb.format.Channels):
+	default:
+	}
+}
+
+func (pb *pcmStreamBackend) bufferDuration() time.Duration {
+	return time.Duration(pb.format.FramesPerBuffer) * time.Second / time.Duration(pb.format.SampleRate)
+}
@@ -0,0 +1,311 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/gordonklaus/portaudio"
+)
+
+func init() {
+	RegisterCaptureBackend("portaudio", newPortAudioBackend)
+	RegisterCaptureDevices("portaudio", listPortAudioDevices)
+}
+
+// portAudioBackend reads from a PortAudio input device. It expects
+// portaudio.Initialize to have been called by the caller.
+type portAudioBackend struct {
+	device *portaudio.DeviceInfo
+	format CaptureFormat
+}
+
+func newPortAudioBackend(cfg CaptureConfig) (CaptureBackend, error) {
+	var device *portaudio.DeviceInfo
+	var err error
+	if cfg.Device != "" {
+		device, err = findPortAudioDevice(cfg.Device)
+	} else {
+		device, err = selectCaptureDevice()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("device selection failed: %w", err)
+	}
+
+	channels := cfg.Channels
+	if channels == 0 {
+		channels = 1
+	}
+	if channels > device.MaxInputChannels {
+		LogInfo("%s only has %d input channels, capturing %d", device.Name, device.MaxInputChannels, device.MaxInputChannels)
+		channels = device.MaxInputChannels
+	}
+
+	return &portAudioBackend{
+		device: device,
+		format: CaptureFormat{
+			SampleRate:      cfg.SampleRate,
+			Channels:        channels,
+			FramesPerBuffer: cfg.FramesPerBuffer,
+			Encoding:        SampleFormatS32LE,
+		},
+	}, nil
+}
+
+func (pa *portAudioBackend) Name() string { return "portaudio" }
+
+func (pa *portAudioBackend) Format() CaptureFormat { return pa.format }
+
+func (pa *portAudioBackend) Start() (chan []float32, func() error, error) {
+	return startPortAudio(pa.device, pa.format.SampleRate, pa.format.FramesPerBuffer, pa.format.Channels)
+}
+
+// findPortAudioDevice resolves --device to exactly one input device, either
+// by index or by its full name (case-insensitive)
+func findPortAudioDevice(name string) (*portaudio.DeviceInfo, error) {
+	devices, err := portaudio.Devices()
+	if err != nil {
+		return nil, fmt.Errorf("failed to enumerate devices: %w", err)
+	}
+
+	if index, err := strconv.Atoi(name); err == nil {
+		for _, device := range devices {
+			if device.Index == index {
+				if device.MaxInputChannels == 0 {
+					return nil, fmt.Errorf("device %d (%s) has no input channels", index, device.Name)
+				}
+				return device, nil
+			}
+		}
+		return nil, fmt.Errorf("no device with index %d (see `termulizer devices`)", index)
+	}
+
+	for _, device := range devices {
+		if device.MaxInputChannels > 0 && strings.EqualFold(device.Name, name) {
+			return device, nil
+		}
+	}
+	return nil, fmt.Errorf("no input device named %q (see `termulizer devices`)", name)
+}
+
+func listPortAudioDevices() ([]CaptureDevice, error) {
+	devices, err := portaudio.Devices()
+	if err != nil {
+		return nil, fmt.Errorf("failed to enumerate devices: %w", err)
+	}
+
+	defaultIndex := -1
+	if def, err := portaudio.DefaultInputDevice(); err == nil && def != nil {
+		defaultIndex = def.Index
+	}
+
+	var inputs []CaptureDevice
+	for _, device := range devices {
+		if device.MaxInputChannels == 0 {
+			continue
+		}
+		inputs = append(inputs, CaptureDevice{
+			Index:       device.Index,
+			Name:        device.Name,
+			Channels:    device.MaxInputChannels,
+			DefaultRate: int(device.DefaultSampleRate),
+			Monitor:     strings.Contains(strings.ToLower(device.Name), "monitor"),
+			Default:     device.Index == defaultIndex,
+		})
+	}
+	return inputs, nil
+}
+
+func selectCaptureDevice() (*portaudio.DeviceInfo, error) {
+	devices, err := portaudio.Devices()
+	if err != nil {
+		return nil, fmt.Errorf("failed to enumerate devices: %w", err)
+	}
+
+	// Get the default sink name from PulseAudio/PipeWire on Linux
+	var defaultSinkMonitor string
+	if runtime.GOOS == "linux" {
+		cmd := pactlCommand("info")
+		if output, err := cmd.Output(); err == nil {
+			// Extract default sink name
+			lines := strings.Split(string(output), "\n")
+			for _, line := range lines {
+				if strings.Contains(line, "Default Sink:") {
+					parts := strings.Split(line, ":")
+					if len(parts) == 2 {
+						sinkName := strings.TrimSpace(parts[1])
+						defaultSinkMonitor = sinkName + ".monitor"
+						log.Printf("Found default sink: %s (looking for monitor: %s)", sinkName, defaultSinkMonitor)
+						break
+					}
+				}
+			}
+		}
+	}
+
+	// First pass: Try to find the monitor of the default sink
+	// Extract key parts of the sink name to match against PortAudio device names
+	if defaultSinkMonitor != "" {
+		// The monitor name often differs slightly from PulseAudio/PipeWire naming
+		// Extract meaningful parts (e.g., "SteelSeries_Arctis" from full name)
+		sinkParts := strings.FieldsFunc(defaultSinkMonitor, func(r rune) bool {
+			return r == '.' || r == '-' || r == '_'
+		})
+
+		for _, device := range devices {
+			if device.MaxInputChannels == 0 {
+				continue
+			}
+			name := strings.ToLower(device.Name)
+
+			// Try to match multiple parts of the sink name
+			matchCount := 0
+			for _, part := range sinkParts {
+				if len(part) > 3 && strings.Contains(name, strings.ToLower(part)) {
+					matchCount++
+				}
+			}
+
+			// If we match at least 2 significant parts, it's likely our device
+			if matchCount >= 2 {
+				log.Printf("✓ Selected monitor of default sink: %s (matched %d parts of %s)",
+					device.Name, matchCount, defaultSinkMonitor)
+				return device, nil
+			}
+		}
+	}
+
+	// Second pass: Try to find any monitor device (more specific than loopback)
+	for _, device := range devices {
+		if device.MaxInputChannels == 0 {
+			continue
+		}
+
+		name := strings.ToLower(device.Name)
+
+		// Skip JACK devices that cause crashes
+		if strings.Contains(name, "jack") {
+			continue
+		}
+
+		// Prioritize monitor devices
+		if strings.Contains(name, "monitor") {
+			log.Printf("✓ Selected monitor device: %s", device.Name)
+			return device, nil
+		}
+	}
+
+	// Third pass: Try loopback devices (less reliable)
+	for _, device := range devices {
+		if device.MaxInputChannels == 0 {
+			continue // Skip output-only devices
+		}
+
+		name := strings.ToLower(device.Name)
+
+		// Skip JACK devices that cause crashes
+		if strings.Contains(name, "jack") {
+			continue
+		}
+
+		// macOS: BlackHole driver
+		// Windows: Stereo Mix, loopback
+		if strings.Contains(name, "blackhole") ||
+			strings.Contains(name, "stereo mix") ||
+			strings.Contains(name, "loopback") ||
+			strings.Contains(name, "what u hear") { // Creative Sound Blaster
+			log.Printf("✓ Selected audio device: %s", device.Name)
+			return device, nil
+		}
+	}
+
+	// Fall back to default input device
+	defaultInput, err := portaudio.DefaultInputDevice()
+	if err != nil {
+		return nil, fmt.Errorf("no suitable audio device found: %w", err)
+	}
+
+	log.Printf("⚠ Using default input device: %s (may not capture system audio)", defaultInput.Name)
+	return defaultInput, nil
+}
+
+func startPortAudio(device *portaudio.DeviceInfo, sampleRate int, framesPerBuf int, inChannels int) (chan []float32, func() error, error) {
+	// Force ALSA to avoid JACK backend issues on Linux
+	os.Setenv("PA_ALSA_PLUGHW", "1")
+	os.Setenv("SDL_AUDIODRIVER", "alsa")
+
+	LogInfo("Selected device: %s", device.Name)
+
+	// Create interleaved Int32 buffer for reading audio
+	bufferInt32 := make([]int32, framesPerBuf*inChannels)
+
+	// Open the selected device rather than whatever PortAudio considers default
+	params := portaudio.StreamParameters{
+		Input: portaudio.StreamDeviceParameters{
+			Device:   device,
+			Channels: inChannels,
+			Latency:  device.DefaultLowInputLatency,
+		},
+		SampleRate:      float64(sampleRate),
+		FramesPerBuffer: framesPerBuf,
+	}
+	stream, err := portaudio.OpenStream(params, bufferInt32)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open stream: %w", err)
+	}
+
+	if err := stream.Start(); err != nil {
+		stream.Close()
+		return nil, nil, fmt.Errorf("failed to start stream: %w", err)
+	}
+
+	ch := make(chan []float32, 8)
+	done := make(chan struct{})
+	doneDone := make(chan struct{})
+
+	// reader loop
+	go func() {
+		defer func() {
+			stream.Stop()
+			stream.Close()
+			close(ch)
+			close(doneDone)
+		}()
+
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				// Read audio data
+				if err := stream.Read(); err != nil {
+					LogError("Stream read error: %v", err)
+					return
+				}
+
+				// Convert Int32 to Float32 normalized to [-1.0, 1.0]
+				buf := make([]float32, len(bufferInt32))
+				for i, sample := range bufferInt32 {
+					buf[i] = float32(sample) / 2147483648.0 // 2^31
+				}
+
+				select {
+				case ch <- buf:
+				default:
+					// Channel full, skip
+				}
+			}
+		}
+	}()
+
+	stop := func() error {
+		close(done)
+		<-doneDone
+		return nil
+	}
+
+	return ch, stop, nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bufio"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+func init() {
+	RegisterCaptureBackend("replay", newReplayBackend)
+}
+
+// replayBackend feeds a recorded session back through the pipeline at its
+// original pace. Recorded metadata replaces the live media session.
+type replayBackend struct {
+	path    string
+	file    *os.File
+	dec     *gob.Decoder
+	format  CaptureFormat
+	started time.Time
+
+	metaMu   sync.Mutex
+	metadata AudioMetadata
+}
+
+// replayRecord is the part of a sessionRecord replay reads. The samples go
+// through the pipeline again, so of each frame only the metadata is needed.
+// Gob matches fields by name, which lets it decode the frames of every
+// session version.
+type replayRecord struct {
+	Offset  time.Duration
+	Samples []float32
+	Frame   *struct{ Metadata AudioMetadata }
+}
+
+func newReplayBackend(cfg CaptureConfig) (CaptureBackend, error) {
+	if cfg.Path == "" {
+		return nil, errors.New("no recording given (use --replay)")
+	}
+
+	f, err := os.Open(cfg.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open recording: %w", err)
+	}
+
+	dec := gob.NewDecoder(bufio.NewReader(f))
+	var header sessionHeader
+	if err := dec.Decode(&header); err != nil || header.Magic != sessionMagic {
+		f.Close()
+		return nil, fmt.Errorf("%s is not a termulizer recording", cfg.Path)
+	}
+	if header.Version > sessionVersion {
+		f.Close()
+		return nil, fmt.Errorf("recording version %d is newer than supported (%d)", header.Version, sessionVersion)
+	}
+
+	return &replayBackend{
+		path:     cfg.Path,
+		file:     f,
+		dec:      dec,
+		format:   header.Format,
+		started:  header.Started,
+		metadata: DefaultMetadata(),
+	}, nil
+}
+
+func (rb *replayBackend) Name() string { return "replay" }
+
+func (rb *replayBackend) Format() CaptureFormat { return rb.format }
+
+func (rb *replayBackend) CurrentMetadata() AudioMetadata {
+	rb.metaMu.Lock()
+	defer rb.metaMu.Unlock()
+	return rb.metadata
+}
+
+func (rb *replayBackend) Start() (chan []float32, func() error, error) {
+	LogInfo("Replaying %s recorded %s (%s)", rb.path, rb.started.Format(time.RFC3339), rb.format)
+
+	ch := make(chan []float32, 8)
+	done := make(chan struct{})
+	finished := make(chan struct{})
+
+	go func() {
+		defer func() {
+			rb.file.Close()
+			close(ch)
+			close(finished)
+		}()
+
+		start := time.Now()
+		for {
+			var rec replayRecord
+			if err := rb.dec.Decode(&rec); err != nil {
+				if err != io.EOF {
+					LogError("Replay decode error: %v", err)
+				}
+				LogInfo("Replay finished")
+				return
+			}
+
+			select {
+			case <-done:
+				return
+			case <-time.After(time.Until(start.Add(rec.Offset))):
+			}
+
+			if rec.Frame != nil {
+				rb.metaMu.Lock()
+				rb.metadata = rec.Frame.Metadata
+				rb.metaMu.Unlock()
+			}
+			if rec.Samples != nil {
+				select {
+				case ch <- rec.Samples:
+				default:
+					// Channel full, skip this buffer
+				}
+			}
+		}
+	}()
+
+	stop := func() error {
+		close(done)
+		<-finished
+		return nil
+	}
+
+	return ch, stop, nil
+}
@@ -0,0 +1,227 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"strings"
+	"time"
+)
+
+const (
+	// How often the default sink is re-checked when `pactl subscribe` isn't available
+	sinkPollInterval = 3 * time.Second
+	reconnectMin     = 500 * time.Millisecond
+	reconnectMax     = 5 * time.Second
+	// A capture that ran this long resets the reconnect backoff
+	stableCapture = 10 * time.Second
+)
+
+var (
+	errSinkChanged   = errors.New("default sink changed")
+	errParecExited   = errors.New("parec exited")
+	errTargetChanged = errors.New("capture target changed")
+)
+
+// supervise keeps parec running until done is closed. It restarts the
+// capture on the new monitor when the default sink changes (unless an
+// explicit --device was given), follows the selected application's stream
+// in --app mode and respawns parec with backoff when it dies, feeding
+// silence in the meantime so the display settles instead of freezing.
+func (pb *parecBackend) supervise(out chan []float32, done chan struct{}) {
+	serverEvents := watchServerEvents(done)
+	backoff := reconnectMin
+
+	for {
+		target, err := pb.resolveTarget()
+		var in chan []float32
+		var stop func() error
+		if err == nil {
+			in, stop, err = StartAudioCapture(target, pb.format.SampleRate, pb.format.FramesPerBuffer, pb.format.Channels)
+		}
+
+		if err != nil {
+			LogError("Capture unavailable: %v", err)
+			pb.report(CaptureReconnecting, err.Error())
+			if !pb.waitWithSilence(out, done, serverEvents, backoff) {
+				return
+			}
+			backoff = min(backoff*2, reconnectMax)
+			continue
+		}
+
+		LogInfo("Capture running on %s", target.label)
+		pb.report(CaptureRunning, target.label)
+		started := time.Now()
+
+		err = pb.forward(in, out, done, serverEvents, target)
+		stop()
+
+		if err == nil {
+			return
+		}
+		if time.Since(started) > stableCapture || errors.Is(err, errSinkChanged) || errors.Is(err, errTargetChanged) {
+			backoff = reconnectMin
+		}
+
+		LogInfo("Restarting capture: %v", err)
+		pb.report(CaptureReconnecting, err.Error())
+		if !pb.waitWithSilence(out, done, serverEvents, backoff) {
+			return
+		}
+		backoff = min(backoff*2, reconnectMax)
+	}
+}
+
+// resolveTarget returns the selected application's stream, the explicit
+// device or the current default monitor, in that order
+func (pb *parecBackend) resolveTarget() (parecTarget, error) {
+	if app := pb.CaptureApp(); app != "" {
+		return pb.resolveAppTarget(app)
+	}
+
+	if pb.device != "" {
+		return parecTarget{source: pb.device, label: pb.device}, nil
+	}
+	sink, err := getDefaultSink()
+	if err != nil {
+		return parecTarget{}, err
+	}
+	monitor := sink + ".monitor"
+	return parecTarget{source: monitor, label: monitor}, nil
+}
+
+// forward copies buffers from one parec instance until it exits, the
+// target moves away, or done is closed (nil error)
+func (pb *parecBackend) forward(in, out chan []float32, done chan struct{}, serverEvents <-chan struct{}, target parecTarget) error {
+	poll := time.NewTicker(sinkPollInterval)
+	defer poll.Stop()
+
+	for {
+		select {
+		case buf, ok := <-in:
+			if !ok {
+				return errParecExited
+			}
+			select {
+			case out <- buf:
+			default:
+				// Channel full, skip this buffer
+			}
+
+		case <-serverEvents:
+			if err := pb.targetMoved(target); err != nil {
+				return err
+			}
+
+		case <-poll.C:
+			if err := pb.targetMoved(target); err != nil {
+				return err
+			}
+
+		case <-pb.retarget:
+			return errTargetChanged
+
+		case <-done:
+			return nil
+		}
+	}
+}
+
+// targetMoved re-resolves the target and reports why it no longer matches
+func (pb *parecBackend) targetMoved(target parecTarget) error {
+	if pb.device != "" && pb.CaptureApp() == "" {
+		return nil
+	}
+	current, err := pb.resolveTarget()
+	switch {
+	case err != nil && target.hasStream:
+		// The application's stream went away
+		return err
+	case err != nil || current == target:
+		return nil
+	case target.hasStream || current.hasStream:
+		return errTargetChanged
+	default:
+		return errSinkChanged
+	}
+}
+
+// waitWithSilence sleeps for d while keeping the pipeline fed, waking early
+// when the server reports a change or the target is switched. Returns false
+// if done was closed in the meantime.
+func (pb *parecBackend) waitWithSilence(out chan []float32, done chan struct{}, serverEvents <-chan struct{}, d time.Duration) bool {
+	bufDuration := time.Duration(pb.format.FramesPerBuffer) * time.Second / time.Duration(pb.format.SampleRate)
+	ticker := time.NewTicker(bufDuration)
+	defer ticker.Stop()
+	deadline := time.After(d)
+
+	for {
+		select {
+		case <-ticker.C:
+			select {
+			case out <- make([]float32, pb.format.FramesPerBuffer*pb.format.Channels):
+			default:
+			}
+		case <-deadline:
+			return true
+		case <-serverEvents:
+			return true
+		case <-pb.retarget:
+			return true
+		case <-done:
+			return false
+		}
+	}
+}
+
+func (pb *parecBackend) report(state CaptureState, detail string) {
+	select {
+	case pb.status <- CaptureStatus{State: state, Detail: detail}:
+	default:
+		// Nobody is listening (or the TUI is behind), drop it
+	}
+}
+
+// watchServerEvents runs `pactl subscribe` and signals whenever the server
+// reports a change (which includes default sink switches) or an application
+// stream comes or goes. If pactl can't be started the channel simply never
+// fires and polling takes over.
+func watchServerEvents(done chan struct{}) <-chan struct{} {
+	events := make(chan struct{}, 1)
+
+	cmd := pactlCommand("subscribe")
+	stdout, err := cmd.StdoutPipe()
+	if err == nil {
+		err = cmd.Start()
+	}
+	if err != nil {
+		LogError("pactl subscribe unavailable, polling for sink changes: %v", err)
+		return events
+	}
+
+	go func() {
+		<-done
+		cmd.Process.Kill()
+	}()
+
+	go func() {
+		defer cmd.Wait()
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			// e.g. "Event 'change' on server #0" or "Event 'new' on sink-input #42"
+			line := scanner.Text()
+			if strings.Contains(line, "on server") || strings.Contains(line, "on sink-input") {
+				select {
+				case events <- struct{}{}:
+				default:
+				}
+			}
+		}
+		LogInfo("pactl subscribe ended, polling for sink changes")
+	}()
+
+	return events
+}
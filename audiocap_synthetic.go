@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+	"strings"
+	"time"
+)
+
+func init() {
+	RegisterCaptureBackend("synthetic", newSyntheticBackend)
+}
+
+// Signals the synthetic backend can produce
+var syntheticSignals = []string{"sweep", "chord", "pink", "white", "clicks", "silence"}
+
+const (
+	syntheticLevel   = 0.5               // peak amplitude of generated signals
+	sweepDuration    = 10.0              // seconds for one 20Hz-20kHz sweep
+	clickDecay       = 0.004             // seconds, exponential decay of a click
+	syntheticDefault = "sweep"           // signal used when none is given
+	syntheticSeed    = int64(0x5EED5EED) // fixed so noise is reproducible
+)
+
+// chordFreqs is an A minor triad over a low A, it lights up bass and mids at once
+var chordFreqs = []float64{55.0, 220.0, 261.63, 329.63}
+
+// SignalGenerator produces deterministic test signals. It needs no audio
+// server, which makes it usable for demos, band tuning and tests.
+type SignalGenerator struct {
+	kind       string
+	sampleRate float64
+	channels   int
+	bpm        float64
+	rng        *rand.Rand
+
+	sample int64   // running sample counter
+	phase  float64 // sweep phase in radians
+	pink   [7]float64
+}
+
+// NewSignalGenerator returns a generator for one of syntheticSignals. bpm is
+// only used by the click track.
+func NewSignalGenerator(kind string, sampleRate, channels int, bpm float64) (*SignalGenerator, error) {
+	if kind == "" {
+		kind = syntheticDefault
+	}
+	kind = strings.ToLower(kind)
+
+	if !slices.Contains(syntheticSignals, kind) {
+		return nil, fmt.Errorf("unknown signal %q (available: %s)", kind, strings.Join(syntheticSignals, ", "))
+	}
+	if bpm <= 0 {
+		return nil, fmt.Errorf("bpm must be positive, got %.1f", bpm)
+	}
+
+	return &SignalGenerator{
+		kind:       kind,
+		sampleRate: float64(sampleRate),
+		channels:   channels,
+		bpm:        bpm,
+		rng:        rand.New(rand.NewSource(syntheticSeed)),
+	}, nil
+}
+
+// Fill writes the next len(buf)/channels frames, the same value on every channel
+func (sg *SignalGenerator) Fill(buf []float32) {
+	for i := 0; i+sg.channels <= len(buf); i += sg.channels {
+		v := float32(sg.next())
+		for c := range sg.channels {
+			buf[i+c] = v
+		}
+	}
+}
+
+func (sg *SignalGenerator) next() float64 {
+	t := float64(sg.sample) / sg.sampleRate
+	sg.sample++
+
+	switch sg.kind {
+	case "sweep":
+		// Logarithmic sweep so every band gets the same time on screen
+		pos := math.Mod(t, sweepDuration) / sweepDuration
+		freq := 20.0 * math.Pow(1000.0, pos)
+		sg.phase += 2 * math.Pi * freq / sg.sampleRate
+		if sg.phase > 2*math.Pi {
+			sg.phase -= 2 * math.Pi
+		}
+		return syntheticLevel * math.Sin(sg.phase)
+
+	case "chord":
+		var sum float64
+		for _, f := range chordFreqs {
+			sum += math.Sin(2 * math.Pi * f * t)
+		}
+		return syntheticLevel * sum / float64(len(chordFreqs))
+
+	case "white":
+		return syntheticLevel * (sg.rng.Float64()*2 - 1)
+
+	case "pink":
+		// Paul Kellet's refined pink noise filter over white noise
+		white := sg.rng.Float64()*2 - 1
+		p := &sg.pink
+		p[0] = 0.99886*p[0] + white*0.0555179
+		p[1] = 0.99332*p[1] + white*0.0750759
+		p[2] = 0.96900*p[2] + white*0.1538520
+		p[3] = 0.86650*p[3] + white*0.3104856
+		p[4] = 0.55000*p[4] + white*0.5329522
+		p[5] = -0.7616*p[5] - white*0.0168980
+		pink := p[0] + p[1] + p[2] + p[3] + p[4] + p[5] + p[6] + white*0.5362
+		p[6] = white * 0.115926
+		return syntheticLevel * pink * 0.11
+
+	case "clicks":
+		beat := 60.0 / sg.bpm
+		since := math.Mod(t, beat)
+		// Accent the downbeat of every bar of four
+		freq, level := 1000.0, syntheticLevel*0.6
+		if int(t/beat)%4 == 0 {
+			freq, level = 1500.0, syntheticLevel
+		}
+		return level * math.Exp(-since/clickDecay) * math.Sin(2*math.Pi*freq*since)
+	}
+
+	return 0 // silence
+}
+
+// syntheticBackend paces a SignalGenerator in real time
+type syntheticBackend struct {
+	gen    *SignalGenerator
+	format CaptureFormat
+}
+
+func newSyntheticBackend(cfg CaptureConfig) (CaptureBackend, error) {
+	channels := cfg.Channels
+	if channels == 0 {
+		channels = 2
+	}
+
+	gen, err := NewSignalGenerator(cfg.Signal, cfg.SampleRate, channels, cfg.BPM)
+	if err != nil {
+		return nil, err
+	}
+
+	return &syntheticBackend{
+		gen: gen,
+		format: CaptureFormat{
+			SampleRate:      cfg.SampleRate,
+			Channels:        channels,
+			FramesPerBuffer: cfg.FramesPerBuffer,
+			Encoding:        SampleFormatF32LE,
+		},
+	}, nil
+}
+
+func (sb *syntheticBackend) Name() string { return "synthetic" }
+
+func (sb *syntheticBackend) Format() CaptureFormat { return sb.format }
+
+func (sb *syntheticBackend) Start() (chan []float32, func() error, error) {
+	LogInfo("Generating %s signal (%s)", sb.gen.kind, sb.format)
+
+	ch := make(chan []float32, 8)
+	done := make(chan struct{})
+	finished := make(chan struct{})
+
+	go func() {
+		defer func() {
+			close(ch)
+			close(finished)
+		}()
+
+		bufDuration := time.Duration(sb.format.FramesPerBuffer) * time.Second / time.Duration(sb.format.SampleRate)
+		next := time.Now()
+
+		for {
+			buf := make([]float32, sb.format.FramesPerBuffer*sb.format.Channels)
+			sb.gen.Fill(buf)
+
+			next = next.Add(bufDuration)
+			select {
+			case <-done:
+				return
+			case <-time.After(time.Until(next)):
+			}
+
+			select {
+			case ch <- buf:
+			default:
+				// Channel full, skip this buffer
+			}
+		}
+	}()
+
+	stop := func() error {
+		close(done)
+		<-finished
+		return nil
+	}
+
+	return ch, stop, nil
+}
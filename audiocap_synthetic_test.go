@@ -0,0 +1,92 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNewSignalGenerator(t *testing.T) {
+	tests := []struct {
+		kind    string
+		bpm     float64
+		wantErr bool
+	}{
+		{"", 120, false},
+		{"sweep", 120, false},
+		{"CHORD", 120, false},
+		{"clicks", 90, false},
+		{"square", 120, true},
+		{"clicks", 0, true},
+		{"clicks", -120, true},
+	}
+	for _, tt := range tests {
+		_, err := NewSignalGenerator(tt.kind, testSampleRate, 2, tt.bpm)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NewSignalGenerator(%q, bpm %g): error %v, want error %v", tt.kind, tt.bpm, err, tt.wantErr)
+		}
+	}
+}
+
+// generate returns seconds of interleaved stereo from a fresh generator
+func generate(t *testing.T, kind string, bpm, seconds float64) []float32 {
+	t.Helper()
+	buf := make([]float32, 2*int(seconds*testSampleRate))
+	generated(t, kind, bpm)(buf)
+	return buf
+}
+
+func TestSignalGeneratorOutput(t *testing.T) {
+	for _, kind := range syntheticSignals {
+		buf := generate(t, kind, 120, 2)
+		again := generate(t, kind, 120, 2)
+
+		var peak float64
+		for i := 0; i < len(buf); i += 2 {
+			if buf[i] != buf[i+1] {
+				t.Fatalf("%s: channels differ at frame %d", kind, i/2)
+			}
+			if buf[i] != again[i] {
+				t.Fatalf("%s: two generators differ at frame %d", kind, i/2)
+			}
+			peak = max(peak, math.Abs(float64(buf[i])))
+		}
+
+		switch {
+		case kind == "silence" && peak != 0:
+			t.Errorf("silence peaks at %.3f", peak)
+		case kind != "silence" && peak == 0:
+			t.Errorf("%s is silent", kind)
+		case kind != "pink" && peak > syntheticLevel+1e-6:
+			t.Errorf("%s peaks at %.3f, over %.1f", kind, peak, syntheticLevel)
+		case peak > 1:
+			t.Errorf("%s clips at %.3f", kind, peak)
+		}
+	}
+}
+
+// A click starts every beat and has died away by the middle of it
+func TestClickSpacing(t *testing.T) {
+	for _, bpm := range []float64{60, 120, 150} {
+		buf := generate(t, "clicks", bpm, 4)
+		beat := 60 / bpm * testSampleRate
+		window := testSampleRate / 200 // 5ms
+
+		energy := func(start int) float64 {
+			var sum float64
+			for i := start; i < start+window; i++ {
+				sum += float64(buf[2*i]) * float64(buf[2*i])
+			}
+			return sum
+		}
+
+		for n := 0; float64(n+1)*beat < 4*testSampleRate; n++ {
+			onset := int(math.Ceil(float64(n) * beat))
+			if e := energy(onset); e < 1 {
+				t.Errorf("%g BPM: no click at beat %d (energy %.3f)", bpm, n, e)
+			}
+			if e := energy(onset + int(beat/2)); e > 1e-6 {
+				t.Errorf("%g BPM: sound between beats %d and %d (energy %.3g)", bpm, n, n+1, e)
+			}
+		}
+	}
+}
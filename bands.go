@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Band layouts selectable with --band-layout
+var bandLayouts = []string{"classic", "log", "octave", "third-octave", "mel", "custom"}
+
+// Nominal ISO 266 values per decade, used to label octave bands (31.5, 63, 125...)
+var isoMantissas = [10]float64{1, 1.25, 1.6, 2, 2.5, 3.15, 4, 5, 6.3, 8}
+
+const (
+	minBands    = 4
+	maxBands    = 128
+	bandLowFreq = 20.0
+	bandTopFreq = 20000.0
+)
+
+// BuildBands returns the analysis bands for a layout. count is the number of
+// bands for the log and mel layouts, edges the ascending band edges in Hz for
+// custom. Bands above Nyquist are dropped.
+func BuildBands(layout string, count int, edges []float64, sampleRate int) ([]FrequencyBand, error) {
+	layout = strings.ToLower(layout)
+	if layout == "" {
+		switch {
+		case len(edges) > 0:
+			layout = "custom"
+		case count == 0 || count == len(frequencyBands):
+			layout = "classic"
+		default:
+			layout = "log"
+		}
+	}
+	if !slices.Contains(bandLayouts, layout) {
+		return nil, fmt.Errorf("unknown band layout %q (available: %s)", layout, strings.Join(bandLayouts, ", "))
+	}
+
+	top := math.Min(bandTopFreq, float64(sampleRate)/2)
+	// These layouts set their own band count
+	fixed := layout == "classic" || layout == "octave" || layout == "third-octave"
+
+	var bands []FrequencyBand
+	switch layout {
+	case "classic":
+		bands = slices.Clone(frequencyBands)
+
+	case "log":
+		if count == 0 {
+			count = 32
+		}
+		bands = bandsFromEdges(logEdges(bandLowFreq, top, count))
+
+	case "mel":
+		if count == 0 {
+			count = 40
+		}
+		lo, hi := hzToMel(bandLowFreq), hzToMel(top)
+		melEdges := make([]float64, count+1)
+		for i := range melEdges {
+			melEdges[i] = melToHz(lo + (hi-lo)*float64(i)/float64(count))
+		}
+		bands = bandsFromEdges(melEdges)
+
+	case "octave", "third-octave":
+		// ISO 266 centre frequencies, base 10: 1kHz * 10^(k/10) for third
+		// octaves, every third one of those for octaves
+		step := 1
+		if layout == "octave" {
+			step = 3
+		}
+		for k := -18; k <= 13; k += step {
+			centre := 1000 * math.Pow(10, float64(k)/10)
+			half := math.Pow(10, float64(step)/20)
+			lo, hi := centre/half, centre*half
+			if lo < bandLowFreq*0.9 || hi > top*1.13 {
+				continue
+			}
+			nominal := isoMantissas[(k%10+10)%10] * 1000 * math.Pow(10, math.Floor(float64(k)/10))
+			bands = append(bands, FrequencyBand{Name: formatFrequency(nominal), MinFreq: lo, MaxFreq: math.Min(hi, top)})
+		}
+
+	case "custom":
+		if len(edges) < minBands+1 {
+			return nil, fmt.Errorf("--band-edges needs at least %d edges, got %d", minBands+1, len(edges))
+		}
+		for i := 1; i < len(edges); i++ {
+			if edges[i] <= edges[i-1] {
+				return nil, fmt.Errorf("--band-edges must be ascending, %.0f follows %.0f", edges[i], edges[i-1])
+			}
+		}
+		bands = bandsFromEdges(edges)
+	}
+
+	// Nothing above Nyquist can be measured
+	bands = slices.DeleteFunc(bands, func(b FrequencyBand) bool { return b.MinFreq >= float64(sampleRate)/2 })
+
+	if fixed && count != 0 && count != len(bands) {
+		return nil, fmt.Errorf("the %s layout has %d bands, --bands %d doesn't apply", layout, len(bands), count)
+	}
+	if len(bands) < minBands || len(bands) > maxBands {
+		return nil, fmt.Errorf("band count must be between %d and %d, got %d", minBands, maxBands, len(bands))
+	}
+
+	return bands, nil
+}
+
+// ParseBandEdges parses a comma separated list of frequencies in Hz
+func ParseBandEdges(s string) ([]float64, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	var edges []float64
+	for _, field := range strings.Split(s, ",") {
+		f, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil || f <= 0 {
+			return nil, fmt.Errorf("invalid band edge %q", field)
+		}
+		edges = append(edges, f)
+	}
+	return edges, nil
+}
+
+func logEdges(lo, hi float64, count int) []float64 {
+	edges := make([]float64, count+1)
+	for i := range edges {
+		edges[i] = lo * math.Pow(hi/lo, float64(i)/float64(count))
+	}
+	return edges
+}
+
+func bandsFromEdges(edges []float64) []FrequencyBand {
+	bands := make([]FrequencyBand, 0, len(edges)-1)
+	for i := 1; i < len(edges); i++ {
+		centre := math.Sqrt(edges[i-1] * edges[i])
+		bands = append(bands, FrequencyBand{Name: formatFrequency(centre), MinFreq: edges[i-1], MaxFreq: edges[i]})
+	}
+	return bands
+}
+
+func hzToMel(f float64) float64 { return 2595 * math.Log10(1+f/700) }
+
+func melToHz(m float64) float64 { return 700 * (math.Pow(10, m/2595) - 1) }
+
+// formatFrequency gives short labels such as "63Hz" or "1.2kHz"
+func formatFrequency(f float64) string {
+	if f >= 1000 {
+		return strconv.FormatFloat(math.Round(f/100)/10, 'f', -1, 64) + "kHz"
+	}
+	return fmt.Sprintf("%.0fHz", f)
+}
+
+// centreFrequency is the geometric centre of a band
+func (fb FrequencyBand) centreFrequency() float64 {
+	return math.Sqrt(math.Max(fb.MinFreq, 1) * fb.MaxFreq)
+}
+
+// bandScaleFactor interpolates the classic per-band scale factors on a log
+// frequency axis, so any layout gets the same roll-off compensation
+func bandScaleFactor(freq float64) float64 {
+	n := len(frequencyBands)
+	first, last := frequencyBands[0].centreFrequency(), frequencyBands[n-1].centreFrequency()
+	if freq <= first {
+		return bandScaleFactors[0]
+	}
+	if freq >= last {
+		return bandScaleFactors[n-1]
+	}
+	for i := 1; i < n; i++ {
+		hi := frequencyBands[i].centreFrequency()
+		if freq <= hi {
+			lo := frequencyBands[i-1].centreFrequency()
+			t := math.Log(freq/lo) / math.Log(hi/lo)
+			return bandScaleFactors[i-1] + t*(bandScaleFactors[i]-bandScaleFactors[i-1])
+		}
+	}
+	return bandScaleFactors[n-1]
+}
+
+// physicsForBand picks low/mid/high physics by the band's position, which
+// matches the original 3/3/3 split for 9 bands
+func physicsForBand(i, n int) BandPhysics {
+	if n < 2 {
+		return midFreqPhysics
+	}
+	pos := float64(i) / float64(n-1)
+	switch {
+	case pos < 1.0/3:
+		return lowFreqPhysics
+	case pos < 2.0/3:
+		return midFreqPhysics
+	default:
+		return highFreqPhysics
+	}
+}
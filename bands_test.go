@@ -0,0 +1,88 @@
+package main
+
+import "testing"
+
+func TestBuildBands(t *testing.T) {
+	tests := []struct {
+		layout     string
+		count      int
+		edges      []float64
+		sampleRate int
+		want       int // bands, 0 for an error
+	}{
+		{"", 0, nil, 44100, 9},
+		{"classic", 0, nil, 44100, 9},
+		{"log", 0, nil, 44100, 32},
+		{"log", 64, nil, 44100, 64},
+		{"mel", 0, nil, 44100, 40},
+		{"octave", 0, nil, 44100, 10},
+		{"third-octave", 0, nil, 44100, 30},
+		{"", 0, []float64{20, 100, 500, 2000, 8000}, 44100, 4},
+		{"custom", 0, []float64{20, 100, 500, 2000, 8000, 16000}, 44100, 5},
+
+		// Bands above Nyquist are dropped
+		{"classic", 0, nil, 16000, 8},
+		{"third-octave", 0, nil, 16000, 26},
+
+		{"wavelet", 0, nil, 44100, 0},
+		{"log", 2, nil, 44100, 0},
+		{"log", 500, nil, 44100, 0},
+		{"octave", 12, nil, 44100, 0},
+		{"custom", 0, nil, 44100, 0},
+		{"custom", 0, []float64{20, 100, 500, 2000}, 44100, 0},
+		{"custom", 0, []float64{20, 500, 100, 2000, 8000}, 44100, 0},
+	}
+	for _, tt := range tests {
+		bands, err := BuildBands(tt.layout, tt.count, tt.edges, tt.sampleRate)
+		switch {
+		case tt.want == 0 && err == nil:
+			t.Errorf("BuildBands(%q, %d, %v, %d): expected an error, got %d bands", tt.layout, tt.count, tt.edges, tt.sampleRate, len(bands))
+		case tt.want != 0 && err != nil:
+			t.Errorf("BuildBands(%q, %d, %v, %d): %v", tt.layout, tt.count, tt.edges, tt.sampleRate, err)
+		case len(bands) != tt.want && tt.want != 0:
+			t.Errorf("BuildBands(%q, %d, %v, %d): got %d bands, want %d", tt.layout, tt.count, tt.edges, tt.sampleRate, len(bands), tt.want)
+		}
+
+		for i := 1; i < len(bands); i++ {
+			if bands[i].MinFreq < bands[i-1].MaxFreq*0.999 || bands[i].MinFreq >= bands[i].MaxFreq {
+				t.Errorf("BuildBands(%q): band %d (%.1f-%.1fHz) doesn't follow %.1f-%.1fHz", tt.layout, i,
+					bands[i].MinFreq, bands[i].MaxFreq, bands[i-1].MinFreq, bands[i-1].MaxFreq)
+			}
+		}
+	}
+}
+
+func TestParseBandEdges(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []float64
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"  ", nil, false},
+		{"20,200,2000", []float64{20, 200, 2000}, false},
+		{" 20 , 63.5,125 ", []float64{20, 63.5, 125}, false},
+		{"20,,200", nil, true},
+		{"20,abc", nil, true},
+		{"20,-5", nil, true},
+		{"0,100", nil, true},
+		{"20,200,", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseBandEdges(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseBandEdges(%q): error %v, want error %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("ParseBandEdges(%q) = %v, want %v", tt.in, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("ParseBandEdges(%q) = %v, want %v", tt.in, got, tt.want)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	barPeakHold       = 400 * time.Millisecond // peaks stay put this long before falling
+	defaultBarGravity = 2.0                    // peak fall acceleration, screen heights per second²
+)
+
+// barEighths are the partial blocks for the top of a bar, by eighths filled
+var barEighths = []rune(" ▁▂▃▄▅▆▇█")
+
+// BarsRenderer is the classic spectrum analyzer: one bar per band growing
+// from the bottom, with peak caps that hang on for a moment and then fall
+type BarsRenderer struct {
+	palette     []lipgloss.Color // scheme colors, spread over the bands
+	colors      []lipgloss.Color
+	levels      []float64 // smoothed with bandPhysics
+	bandPhysics []BandPhysics
+	cache       *RenderCache
+
+	// Peak caps, in screen heights, falling under gravity after barPeakHold
+	peaks      []float64
+	peakSpeed  []float64
+	peakTime   []time.Time
+	lastRender time.Time
+
+	barWidth int // cells per bar, 0 fills the width
+	barGap   int // cells between bars
+	gravity  float64
+}
+
+func NewBarsRenderer() *BarsRenderer {
+	br := &BarsRenderer{
+		palette: beamDefaultColors,
+		cache:   NewRenderCache(),
+		barGap:  1,
+		gravity: defaultBarGravity,
+	}
+	br.resize(len(frequencyBands))
+	return br
+}
+
+// resize adapts per-band state to a new band count
+func (br *BarsRenderer) resize(n int) {
+	br.levels = make([]float64, n)
+	br.peaks = make([]float64, n)
+	br.peakSpeed = make([]float64, n)
+	br.peakTime = make([]time.Time, n)
+	br.bandPhysics = make([]BandPhysics, n)
+	for i := range n {
+		br.bandPhysics[i] = physicsForBand(i, n)
+	}
+	br.colors = spreadPalette(br.palette, n)
+}
+
+// SetLayout sets the bar width and the gap between bars in cells. Bars that
+// don't fit at that width are narrowed to fill the screen.
+func (br *BarsRenderer) SetLayout(width, gap int) {
+	br.barWidth, br.barGap = max(width, 0), max(gap, 0)
+}
+
+// SetGravity sets how fast the peak caps fall, in screen heights per second²
+func (br *BarsRenderer) SetGravity(gravity float64) {
+	br.gravity = gravity
+}
+
+func (br *BarsRenderer) SetColorScheme(scheme string) {
+	switch scheme {
+	case "retro":
+		br.palette = beamRetroColors
+	default:
+		br.palette = beamDefaultColors
+	}
+	br.colors = spreadPalette(br.palette, len(br.colors))
+}
+
+// Update eases the bars towards the frame's bands, fast up and slower down
+func (br *BarsRenderer) Update(frame AudioFrame) {
+	if len(frame.Bands) != len(br.levels) {
+		br.resize(len(frame.Bands))
+	}
+	for i, energy := range frame.Bands {
+		physics := br.bandPhysics[i]
+		rate := physics.Decay
+		if energy > br.levels[i] {
+			rate = physics.Attack
+		}
+		br.levels[i] += (math.Max(0, math.Min(1, energy)) - br.levels[i]) * rate
+	}
+}
+
+// Reset drops the bars and the peaks
+func (br *BarsRenderer) Reset() {
+	br.resize(len(br.levels))
+}
+
+// updatePeaks pushes the caps up to the bars and lets them fall once they've
+// been held long enough. Time based, View isn't called at a fixed rate.
+func (br *BarsRenderer) updatePeaks(now time.Time) {
+	dt := 0.0
+	if !br.lastRender.IsZero() {
+		dt = math.Min(now.Sub(br.lastRender).Seconds(), 0.25)
+	}
+	br.lastRender = now
+
+	for i, level := range br.levels {
+		if level >= br.peaks[i] {
+			br.peaks[i], br.peakSpeed[i], br.peakTime[i] = level, 0, now
+			continue
+		}
+		if now.Sub(br.peakTime[i]) < barPeakHold {
+			continue
+		}
+		br.peakSpeed[i] += br.gravity * dt
+		br.peaks[i] = math.Max(br.peaks[i]-br.peakSpeed[i]*dt, level)
+	}
+}
+
+// layout fits the bars into width cells: bar width, gap and left margin
+func (br *BarsRenderer) layout(width, n int) (int, int, int) {
+	barW, gap := br.barWidth, br.barGap
+	if barW == 0 || n*barW+(n-1)*gap > width {
+		barW = (width - (n-1)*gap) / n
+	}
+	if barW < 1 {
+		// Too many bands for gaps, pack them
+		barW, gap = 1, 0
+	}
+	total := n*barW + (n-1)*gap
+	return barW, gap, max((width-total)/2, 0)
+}
+
+func (br *BarsRenderer) Render(width, height int) string {
+	n := len(br.levels)
+	if n == 0 || width < 1 || height < 1 {
+		return strings.Repeat("\n", max(height-1, 0))
+	}
+	br.updatePeaks(time.Now())
+
+	grid, colorGrid, intensityGrid := br.cache.GetGrids(height, width)
+	defer br.cache.ReturnGrids(grid, colorGrid, intensityGrid)
+
+	barW, gap, left := br.layout(width, n)
+	for i, level := range br.levels {
+		x0 := left + i*(barW+gap)
+		if x0 >= width {
+			break
+		}
+		x1 := min(x0+barW, width)
+
+		// Whole cells, then the partial block on top in eighths
+		filled := level * float64(height)
+		full := int(filled)
+		eighths := int((filled - float64(full)) * 8)
+		top := full // first row the bar leaves empty
+		if eighths > 0 {
+			top++
+		}
+		for row := 0; row < height && row <= full; row++ {
+			glyph := barEighths[8]
+			if row == full {
+				if eighths == 0 {
+					break
+				}
+				glyph = barEighths[eighths]
+			}
+			// Brighter towards the top of the screen
+			color := br.cache.ApplyGradient(br.colors[i], 0.35+0.5*float64(row+1)/float64(height))
+			y := height - 1 - row
+			for x := x0; x < x1; x++ {
+				grid[y][x], colorGrid[y][x] = glyph, color
+			}
+		}
+
+		// The cap sits at the bottom or top of its cell, whichever is closer,
+		// unless the bar already reaches into that cell
+		peak := br.peaks[i] * float64(height)
+		row := min(int(peak), height-1)
+		if br.peaks[i] < 0.02 || row < top {
+			continue
+		}
+		glyph := '▁'
+		if peak-float64(row) >= 0.5 {
+			glyph = '▔'
+		}
+		color := br.cache.ApplyGradient(br.colors[i], 0.9)
+		y := height - 1 - row
+		for x := x0; x < x1; x++ {
+			grid[y][x], colorGrid[y][x] = glyph, color
+		}
+	}
+
+	return br.gridToString(grid, colorGrid, width, height)
+}
+
+// gridToString renders the glyphs, grouping runs of the same color into one style
+func (br *BarsRenderer) gridToString(grid [][]rune, colorGrid [][]lipgloss.Color, width, height int) string {
+	sb := br.cache.GetBuilder()
+	defer br.cache.ReturnBuilder(sb)
+
+	for y := range height {
+		x := 0
+		for x < width {
+			if grid[y][x] == ' ' {
+				sb.WriteByte(' ')
+				x++
+				continue
+			}
+			color := colorGrid[y][x]
+			start := x
+			for x < width && grid[y][x] != ' ' && colorGrid[y][x] == color {
+				x++
+			}
+			sb.WriteString(br.cache.GetStyle(color).Render(string(grid[y][start:x])))
+		}
+		if y < height-1 {
+			sb.WriteByte('\n')
+		}
+	}
+	return sb.String()
+}
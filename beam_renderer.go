@@ -4,11 +4,12 @@ import (
 	"math"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 )
 
-var beamDefaultColors = [9]lipgloss.Color{
+var beamDefaultColors = []lipgloss.Color{
 	lipgloss.Color("#5A0000"), // Dark red
 	lipgloss.Color("#E10600"), // Red
 	lipgloss.Color("#FF7A00"), // Orange
@@ -20,7 +21,7 @@ var beamDefaultColors = [9]lipgloss.Color{
 	lipgloss.Color("#FF00C8"), // Magenta
 }
 
-var beamRetroColors = [9]lipgloss.Color{
+var beamRetroColors = []lipgloss.Color{
 	lipgloss.Color("#FF0080"),
 	lipgloss.Color("#FF0099"),
 	lipgloss.Color("#FF00CC"),
@@ -55,47 +56,98 @@ type BandPhysics struct {
 }
 
 type BeamRenderer struct {
-	colors           [9]lipgloss.Color
+	palette          []lipgloss.Color // scheme colors, spread over the bands
+	colors           []lipgloss.Color
 	noiseGen         *NoiseGenerator
-	smoothedEnergies [9]float64 // Smoothed energy values
-	previousEnergies [9]float64
-	bandPhysics      [9]BandPhysics
+	smoothedEnergies []float64 // Smoothed energy values
+	previousEnergies []float64
+	bandPhysics      []BandPhysics
 	chaosSmooth      float64
 	cache            *RenderCache
+
+	// Copies of the latest frame's bands and chaos, drawn on the next Render
+	bands      []float64
+	chaosLevel float64
+
+	// Beat reaction, raised by Trigger and decaying between renders
+	pulse      float64   // whole display, from beats
+	flashes    []float64 // per beam, from band onsets
+	lastRender time.Time
 }
 
+// Half-life of beat pulses and onset flashes
+const flashHalfLife = 90 * time.Millisecond
+
 func NewBeamRenderer(noiseGen *NoiseGenerator) *BeamRenderer {
-	return &BeamRenderer{
-		colors:           beamDefaultColors,
-		noiseGen:         noiseGen,
-		smoothedEnergies: [9]float64{},
-		previousEnergies: [9]float64{},
-		bandPhysics: [9]BandPhysics{
-			lowFreqPhysics,
-			lowFreqPhysics,
-			lowFreqPhysics,
-			midFreqPhysics,
-			midFreqPhysics,
-			midFreqPhysics,
-			highFreqPhysics,
-			highFreqPhysics,
-			highFreqPhysics,
-		},
+	br := &BeamRenderer{
+		palette:     beamDefaultColors,
+		noiseGen:    noiseGen,
 		chaosSmooth: 0.0,
 		cache:       NewRenderCache(),
 	}
+	br.resize(len(frequencyBands))
+	return br
+}
+
+// resize adapts per-band state to a new band count
+func (br *BeamRenderer) resize(n int) {
+	br.smoothedEnergies = make([]float64, n)
+	br.previousEnergies = make([]float64, n)
+	br.flashes = make([]float64, n)
+	br.bandPhysics = make([]BandPhysics, n)
+	for i := range n {
+		br.bandPhysics[i] = physicsForBand(i, n)
+	}
+	br.colors = spreadPalette(br.palette, n)
 }
 
 func (br *BeamRenderer) SetColorScheme(scheme string) {
 	switch scheme {
 	case "retro":
-		br.colors = beamRetroColors
+		br.palette = beamRetroColors
 	default:
-		br.colors = beamDefaultColors
+		br.palette = beamDefaultColors
+	}
+	br.colors = spreadPalette(br.palette, len(br.colors))
+}
+
+// Trigger feeds a frame's onsets to the renderer: beats pulse every beam,
+// band onsets flash the beam they happened in
+func (br *BeamRenderer) Trigger(beat bool, strength float64, bandOnsets []float64) {
+	if beat {
+		br.pulse = math.Max(br.pulse, 0.5+strength*0.5)
+	}
+	for i, onset := range bandOnsets {
+		if i < len(br.flashes) {
+			br.flashes[i] = math.Max(br.flashes[i], onset)
+		}
 	}
 }
 
-func (br *BeamRenderer) RenderPlasmaBeams(bands [9]float64, chaosLevel float64, width int, height int) string {
+// Update keeps the frame's bands for the next Render. Onsets only last one frame,
+// Trigger latches them.
+func (br *BeamRenderer) Update(frame AudioFrame) {
+	br.bands, br.chaosLevel = append(br.bands[:0], frame.Bands...), frame.ChaosLevel
+	br.Trigger(frame.Beat, frame.OnsetStrength, frame.BandOnsets)
+}
+
+func (br *BeamRenderer) Render(width, height int) string {
+	return br.RenderPlasmaBeams(br.bands, br.chaosLevel, width, height)
+}
+
+// Reset drops the smoothing and any pending flashes
+func (br *BeamRenderer) Reset() {
+	br.resize(len(br.smoothedEnergies))
+	br.chaosSmooth, br.pulse = 0, 0
+	br.bands, br.chaosLevel = nil, 0
+}
+
+// RenderPlasmaBeams draws one beam per band, however many it gets
+func (br *BeamRenderer) RenderPlasmaBeams(bands []float64, chaosLevel float64, width int, height int) string {
+	if len(bands) != len(br.smoothedEnergies) {
+		br.resize(len(bands))
+	}
+
 	// Smoother transitions for "liquid" feel
 	for i := range bands {
 		newEnergy := bands[i]
@@ -111,13 +163,26 @@ func (br *BeamRenderer) RenderPlasmaBeams(bands [9]float64, chaosLevel float64,
 		br.previousEnergies[i] = br.smoothedEnergies[i]
 	}
 
+	// Time based decay, View isn't called at a fixed rate
+	now := time.Now()
+	if !br.lastRender.IsZero() {
+		decay := math.Pow(0.5, float64(now.Sub(br.lastRender))/float64(flashHalfLife))
+		br.pulse *= decay
+		for i := range br.flashes {
+			br.flashes[i] *= decay
+		}
+	}
+	br.lastRender = now
+
 	renderHeight := height * 2
 
 	// Calculate beam spacing - divide width by number of beams
-	numBeams := 9
+	numBeams := len(bands)
 	padding := 2
 	usableWidth := width - (padding * 2)
-	beamSpacing := usableWidth / (numBeams + 1)
+	beamSpacing := float64(usableWidth) / float64(numBeams+1)
+	// Dense layouts get thinner, calmer beams so neighbours stay apart
+	beamScale := math.Min(1.0, beamSpacing/8.0)
 
 	// Use cache to get grids (reused memory) - using doubled height
 	grid, colorGrid, intensityGrid := br.cache.GetGrids(renderHeight, width)
@@ -132,11 +197,12 @@ func (br *BeamRenderer) RenderPlasmaBeams(bands [9]float64, chaosLevel float64,
 		wg.Add(1)
 		go func(idx int) {
 			defer wg.Done()
-			baseX := padding + (idx+1)*beamSpacing
+			baseX := padding + int(float64(idx+1)*beamSpacing)
 			energy := br.smoothedEnergies[idx]
 			color := br.colors[idx]
+			flash := math.Max(br.pulse*0.6, br.flashes[idx])
 
-			br.renderVerticalBeamParallel(colorGrid, intensityGrid, baseX, renderHeight, width, energy, color, idx, &gridMu)
+			br.renderVerticalBeamParallel(colorGrid, intensityGrid, baseX, renderHeight, width, energy, flash, color, idx, beamScale, &gridMu)
 		}(beamIdx)
 	}
 	wg.Wait()
@@ -152,13 +218,16 @@ func (br *BeamRenderer) renderVerticalBeamParallel(
 	height int,
 	width int,
 	energy float64,
+	flash float64,
 	color lipgloss.Color,
 	beamIdx int,
+	scale float64,
 	mu *sync.Mutex,
 ) {
 	// Focused beam: Thinner core, smaller overall footprint
 	// coreWidth ranges from 0.8 to 3.0. This keeps them as "strands".
-	coreWidth := 0.8 + (energy * 2.2)
+	// Onsets swell the beam for a moment
+	coreWidth := (0.8 + (energy * 2.2)) * math.Max(scale, 0.3) * (1 + flash*0.6)
 	time := br.noiseGen.time
 
 	for y := range height {
@@ -171,7 +240,7 @@ func (br *BeamRenderer) renderVerticalBeamParallel(
 			0.45,
 		)
 
-		distortionAmp := (2.5 + energy*6.0) * (0.8 + br.chaosSmooth*1.2)
+		distortionAmp := (2.5 + energy*6.0) * (0.8 + br.chaosSmooth*1.2) * scale
 		xOffset := noiseX * distortionAmp
 
 		// Add high-frequency jitter for "electricity" feel during high chaos/energy
@@ -209,7 +278,8 @@ func (br *BeamRenderer) renderVerticalBeamParallel(
 				continue
 			}
 
-			intensity := energy * falloff
+			// Flashes brighten towards the white-hot core
+			intensity := math.Min(1.0, (energy+flash*0.5)*falloff)
 
 			flicker := 0.92 + 0.16*math.Sin(time*25.0+float64(y)*0.6)
 			intensity *= flicker
@@ -300,7 +370,7 @@ func (br *BeamRenderer) gridToStringHalfBlock(
 }
 
 func (br *BeamRenderer) SetBandPhysics(bandIndex int, attack, decay float64) {
-	if bandIndex >= 0 && bandIndex < 9 {
+	if bandIndex >= 0 && bandIndex < len(br.bandPhysics) {
 		br.bandPhysics[bandIndex] = BandPhysics{
 			Attack: attack,
 			Decay:  decay,
@@ -312,11 +382,11 @@ func (br *BeamRenderer) SetBandPhysics(bandIndex int, attack, decay float64) {
 func (br *BeamRenderer) SetPhysicsProfile(profile string) {
 	switch profile {
 	case "fast":
-		for i := range 9 {
+		for i := range br.bandPhysics {
 			br.bandPhysics[i] = BandPhysics{Attack: 0.95, Decay: 0.50}
 		}
 	case "slow":
-		for i := range 9 {
+		for i := range br.bandPhysics {
 			br.bandPhysics[i] = BandPhysics{Attack: 0.75, Decay: 0.10}
 		}
 	}
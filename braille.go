@@ -0,0 +1,125 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// brailleDots maps a dot inside a 2x4 braille cell to its bit in U+2800
+var brailleDots = [4][2]rune{
+	{0x01, 0x08},
+	{0x02, 0x10},
+	{0x04, 0x20},
+	{0x40, 0x80},
+}
+
+// BrailleCanvas is a monochrome-per-cell drawing surface with 2x4 dots per
+// terminal cell. Dots are roughly square on a typical terminal font.
+type BrailleCanvas struct {
+	width, height int // in cells
+	cells         []rune
+	colors        []lipgloss.Color
+}
+
+func NewBrailleCanvas(width, height int) *BrailleCanvas {
+	bc := &BrailleCanvas{}
+	bc.Resize(width, height)
+	return bc
+}
+
+// Resize changes the canvas size in cells and clears it
+func (bc *BrailleCanvas) Resize(width, height int) {
+	width, height = max(width, 0), max(height, 0)
+	if width != bc.width || height != bc.height {
+		bc.width, bc.height = width, height
+		bc.cells = make([]rune, width*height)
+		bc.colors = make([]lipgloss.Color, width*height)
+	}
+	bc.Clear()
+}
+
+func (bc *BrailleCanvas) Clear() {
+	for i := range bc.cells {
+		bc.cells[i] = 0
+		bc.colors[i] = ""
+	}
+}
+
+// DotSize is the canvas size in dots
+func (bc *BrailleCanvas) DotSize() (int, int) {
+	return bc.width * 2, bc.height * 4
+}
+
+// Set lights the dot at x, y (in dots), the cell takes the given color.
+// Dots outside the canvas are ignored.
+func (bc *BrailleCanvas) Set(x, y int, color lipgloss.Color) {
+	if x < 0 || y < 0 || x >= bc.width*2 || y >= bc.height*4 {
+		return
+	}
+	i := (y/4)*bc.width + x/2
+	bc.cells[i] |= brailleDots[y%4][x%2]
+	bc.colors[i] = color
+}
+
+// Line draws a straight line between two dots
+func (bc *BrailleCanvas) Line(x0, y0, x1, y1 int, color lipgloss.Color) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	err := dx + dy
+	for {
+		bc.Set(x0, y0, color)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+// String renders the canvas, grouping runs of the same color into one style
+func (bc *BrailleCanvas) String(cache *RenderCache) string {
+	var sb strings.Builder
+	for y := range bc.height {
+		row := y * bc.width
+		x := 0
+		for x < bc.width {
+			if bc.cells[row+x] == 0 {
+				sb.WriteByte(' ')
+				x++
+				continue
+			}
+			color := bc.colors[row+x]
+			var run strings.Builder
+			for x < bc.width && bc.cells[row+x] != 0 && bc.colors[row+x] == color {
+				run.WriteRune(0x2800 + bc.cells[row+x])
+				x++
+			}
+			sb.WriteString(cache.GetStyle(color).Render(run.String()))
+		}
+		if y < bc.height-1 {
+			sb.WriteByte('\n')
+		}
+	}
+	return sb.String()
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
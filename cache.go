@@ -201,6 +201,36 @@ func (pc *RenderCache) BlendColors(c1, c2 lipgloss.Color, ratio float64) lipglos
 	return uint8ToHex(r, g, b)
 }
 
+// spreadPalette stretches a palette over n bands by interpolating between
+// its colors, so any band count runs through the whole scheme
+func spreadPalette(palette []lipgloss.Color, n int) []lipgloss.Color {
+	colors := make([]lipgloss.Color, n)
+	if len(palette) == 0 {
+		return colors
+	}
+	for i := range colors {
+		pos := 0.0
+		if n > 1 {
+			pos = float64(i) / float64(n-1) * float64(len(palette)-1)
+		}
+		lo := int(pos)
+		hi := min(lo+1, len(palette)-1)
+		r1, g1, b1, ok1 := parseHex(string(palette[lo]))
+		r2, g2, b2, ok2 := parseHex(string(palette[hi]))
+		if !ok1 || !ok2 {
+			colors[i] = palette[lo]
+			continue
+		}
+		t := pos - float64(lo)
+		colors[i] = uint8ToHex(
+			uint8(float64(r1)*(1-t)+float64(r2)*t),
+			uint8(float64(g1)*(1-t)+float64(g2)*t),
+			uint8(float64(b1)*(1-t)+float64(b2)*t),
+		)
+	}
+	return colors
+}
+
 func uint8ToHex(r, g, b uint8) lipgloss.Color {
 	const hex = "0123456789ABCDEF"
 	var res [7]byte
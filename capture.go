@@ -0,0 +1,228 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+)
+
+// SampleFormat names the on-the-wire encoding a backend reads before it
+// converts samples to normalized float32
+type SampleFormat string
+
+const (
+	SampleFormatF32LE SampleFormat = "f32le"
+	SampleFormatS16LE SampleFormat = "s16le"
+	SampleFormatS32LE SampleFormat = "s32le"
+)
+
+// CaptureFormat describes the stream a backend delivers on its channel.
+// Buffers are always interleaved float32 in [-1, 1], Channels samples per frame.
+type CaptureFormat struct {
+	SampleRate      int
+	Channels        int
+	FramesPerBuffer int
+	Encoding        SampleFormat
+}
+
+func (f CaptureFormat) String() string {
+	return fmt.Sprintf("%dHz %dch %s, %d frames/buffer", f.SampleRate, f.Channels, f.Encoding, f.FramesPerBuffer)
+}
+
+// CaptureConfig is what the user asked for; backends may override fields
+// they can't honor and report the result through Format()
+type CaptureConfig struct {
+	SampleRate      int
+	FramesPerBuffer int
+	Channels        int          // 0 = backend default
+	Device          string       // exact device/source name, empty = auto
+	App             string       // capture a single application's stream (parec)
+	Encoding        SampleFormat // raw PCM encoding for stream backends
+	Path            string       // input file or FIFO for file based backends
+	Mute            bool         // don't play file input out loud
+	Signal          string       // synthetic backend signal kind
+	BPM             float64      // synthetic click track tempo
+}
+
+// CaptureBackend is a source of interleaved float32 audio buffers.
+// Start returns the same (channel, stop, error) triple the original
+// capture functions did; the channel is closed when the source ends.
+type CaptureBackend interface {
+	Name() string
+	Format() CaptureFormat
+	Start() (chan []float32, func() error, error)
+}
+
+// CaptureState is what a long-running backend is currently doing
+type CaptureState int
+
+const (
+	CaptureRunning CaptureState = iota
+	CaptureReconnecting
+)
+
+// CaptureStatus is sent by backends that recover from failures on their
+// own, so the TUI can show what's going on instead of a frozen display
+type CaptureStatus struct {
+	State  CaptureState
+	Detail string // source name while running, reason while reconnecting
+}
+
+// captureStatusReporter is implemented by backends that publish CaptureStatus
+type captureStatusReporter interface {
+	Status() <-chan CaptureStatus
+}
+
+// appFollowPlayer makes --app track whichever MPRIS player is playing
+const appFollowPlayer = "playing"
+
+// AppSelector is implemented by backends that can narrow capture down to a
+// single application at runtime. An empty name means all system audio.
+type AppSelector interface {
+	CaptureApp() string
+	SetCaptureApp(name string)
+	CaptureApps() []string
+}
+
+// CaptureBackendFactory builds a backend from the requested config
+type CaptureBackendFactory func(cfg CaptureConfig) (CaptureBackend, error)
+
+// CaptureDevice is one input a backend can record from, as printed by
+// `termulizer devices`
+type CaptureDevice struct {
+	Index       int
+	Name        string
+	Channels    int
+	DefaultRate int
+	Monitor     bool // captures what a sink is playing rather than a mic
+	Default     bool
+}
+
+var (
+	captureBackendsMu sync.RWMutex
+	captureBackends   = map[string]CaptureBackendFactory{}
+	captureDeviceList = map[string]func() ([]CaptureDevice, error){}
+)
+
+// RegisterCaptureBackend makes a backend selectable with --backend.
+// Platform specific backends register themselves from init().
+func RegisterCaptureBackend(name string, factory CaptureBackendFactory) {
+	captureBackendsMu.Lock()
+	defer captureBackendsMu.Unlock()
+	captureBackends[name] = factory
+}
+
+// RegisterCaptureDevices adds a device enumerator for `termulizer devices`
+func RegisterCaptureDevices(backend string, list func() ([]CaptureDevice, error)) {
+	captureBackendsMu.Lock()
+	defer captureBackendsMu.Unlock()
+	captureDeviceList[backend] = list
+}
+
+// CaptureBackendNames lists registered backends in sorted order
+func CaptureBackendNames() []string {
+	captureBackendsMu.RLock()
+	defer captureBackendsMu.RUnlock()
+
+	names := make([]string, 0, len(captureBackends))
+	for name := range captureBackends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewCaptureBackend looks up a backend by name and constructs it.
+// An empty name picks the platform default.
+func NewCaptureBackend(name string, cfg CaptureConfig) (CaptureBackend, error) {
+	if name == "" {
+		name = defaultCaptureBackend()
+	}
+
+	captureBackendsMu.RLock()
+	factory, ok := captureBackends[strings.ToLower(name)]
+	captureBackendsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown capture backend %q (available: %s)", name, strings.Join(CaptureBackendNames(), ", "))
+	}
+
+	backend, err := factory(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("%s backend: %w", name, err)
+	}
+	return backend, nil
+}
+
+// printCaptureDevices lists every input-capable source of each backend
+// that can enumerate them
+func printCaptureDevices(w io.Writer) error {
+	captureBackendsMu.RLock()
+	backends := make([]string, 0, len(captureDeviceList))
+	for name := range captureDeviceList {
+		backends = append(backends, name)
+	}
+	captureBackendsMu.RUnlock()
+	sort.Strings(backends)
+
+	var failed []string
+	for _, backend := range backends {
+		captureBackendsMu.RLock()
+		list := captureDeviceList[backend]
+		captureBackendsMu.RUnlock()
+
+		devices, err := list()
+		if err != nil {
+			LogError("Listing %s devices failed: %v", backend, err)
+			fmt.Fprintf(w, "%s: %v\n\n", backend, err)
+			failed = append(failed, backend)
+			continue
+		}
+
+		fmt.Fprintf(w, "%s (use with --backend %s --device <index or name>)\n", backend, backend)
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "  INDEX\tCH\tRATE\tMONITOR\tNAME")
+		for _, d := range devices {
+			monitor := "no"
+			if d.Monitor {
+				monitor = "yes"
+			}
+			name := d.Name
+			if d.Default {
+				name += " (default)"
+			}
+			fmt.Fprintf(tw, "  %d\t%d\t%d\t%s\t%s\n", d.Index, d.Channels, d.DefaultRate, monitor, name)
+		}
+		tw.Flush()
+		fmt.Fprintln(w)
+	}
+
+	if len(failed) == len(backends) && len(backends) > 0 {
+		return fmt.Errorf("no backend could list devices (%s)", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// defaultCaptureBackend prefers parec where it's available since it
+// reliably finds the monitor of the default sink, then PortAudio
+func defaultCaptureBackend() string {
+	captureBackendsMu.RLock()
+	defer captureBackendsMu.RUnlock()
+
+	if _, ok := captureBackends["parec"]; ok {
+		return "parec"
+	}
+	return "portaudio"
+}
+
+// pactlCommand runs pactl in the C locale, its output is translated otherwise
+// and the parsers look for the English headers
+func pactlCommand(args ...string) *exec.Cmd {
+	cmd := exec.Command("pactl", args...)
+	cmd.Env = append(os.Environ(), "LC_ALL=C")
+	return cmd
+}
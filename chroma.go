@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"time"
+)
+
+const (
+	chromaMinFreq = 55.0            // A1, lower notes are too close together for the FFT bins
+	chromaMaxFreq = 5000.0          // above this it's mostly harmonics and noise
+	keyWindow     = 8 * time.Second // chroma history the key estimate averages over
+	keyMinConf    = 0.5             // correlation below which no key is reported
+)
+
+var pitchClassNames = [12]string{"C", "C#", "D", "Eb", "E", "F", "F#", "G", "Ab", "A", "Bb", "B"}
+
+// Krumhansl-Kessler key profiles, tonic first
+var (
+	majorProfile = [12]float64{6.35, 2.23, 3.48, 2.33, 4.38, 4.09, 2.52, 5.19, 2.39, 3.66, 2.29, 2.88}
+	minorProfile = [12]float64{6.33, 2.68, 3.52, 5.38, 2.60, 3.53, 2.54, 4.75, 3.98, 2.69, 3.34, 3.17}
+)
+
+// MusicalKey is an estimated key, Tonic is a pitch class (0 = C) or -1 while
+// unknown
+type MusicalKey struct {
+	Tonic int
+	Minor bool
+}
+
+var unknownKey = MusicalKey{Tonic: -1}
+
+func (k MusicalKey) Known() bool {
+	return k.Tonic >= 0
+}
+
+func (k MusicalKey) String() string {
+	if !k.Known() {
+		return "-"
+	}
+	if k.Minor {
+		return pitchClassNames[k.Tonic] + " minor"
+	}
+	return pitchClassNames[k.Tonic] + " major"
+}
+
+// Camelot is the DJ wheel notation, e.g. 8A for A minor and 8B for C major
+func (k MusicalKey) Camelot() string {
+	if !k.Known() {
+		return "-"
+	}
+	major, letter := k.Tonic, "B"
+	if k.Minor {
+		major, letter = (k.Tonic+3)%12, "A" // relative major shares the number
+	}
+	return fmt.Sprintf("%d%s", (major*7+7)%12+1, letter)
+}
+
+// ChromaAnalyzer folds the spectrum into 12 pitch classes and estimates the
+// key by correlating the averaged chroma with the Krumhansl profiles
+type ChromaAnalyzer struct {
+	pitchClass []int // per FFT bin, -1 outside the chroma range
+	alpha      float64
+	chroma     [12]float64 // scratch for the current frame
+	average    [12]float64 // exponentially weighted chroma for the key
+}
+
+func NewChromaAnalyzer(sampleRate, fftSize, hopSize int) *ChromaAnalyzer {
+	bins := fftSize/2 + 1
+	binWidth := float64(sampleRate) / float64(fftSize)
+	pitchClass := make([]int, bins)
+	for j := range pitchClass {
+		freq := float64(j) * binWidth
+		if freq < chromaMinFreq || freq > chromaMaxFreq {
+			pitchClass[j] = -1
+			continue
+		}
+		// MIDI note number, 69 is A4
+		note := int(math.Round(69 + 12*math.Log2(freq/440)))
+		pitchClass[j] = note % 12
+	}
+
+	hop := float64(hopSize) / float64(sampleRate)
+	return &ChromaAnalyzer{
+		pitchClass: pitchClass,
+		alpha:      hop / keyWindow.Seconds(),
+	}
+}
+
+// Process returns the chroma of one frame (0-1, loudest pitch class at 1)
+// along with the current key estimate and its confidence
+func (ca *ChromaAnalyzer) Process(left, right []complex128) ([12]float64, MusicalKey, float64) {
+	ca.chroma = [12]float64{}
+	for j, pc := range ca.pitchClass {
+		if pc < 0 {
+			continue
+		}
+		mag := cmplx.Abs(left[j] + right[j])
+		ca.chroma[pc] += mag * mag
+	}
+
+	peak := 0.0
+	for _, c := range ca.chroma {
+		peak = math.Max(peak, c)
+	}
+	if peak < 1e-12 {
+		// Silence doesn't move the key estimate
+		key, conf := ca.estimateKey()
+		return [12]float64{}, key, conf
+	}
+	for i := range ca.chroma {
+		ca.chroma[i] /= peak
+		ca.average[i] += (ca.chroma[i] - ca.average[i]) * ca.alpha
+	}
+
+	key, conf := ca.estimateKey()
+	return ca.chroma, key, conf
+}
+
+// estimateKey picks the best of the 24 rotated profiles by Pearson correlation
+func (ca *ChromaAnalyzer) estimateKey() (MusicalKey, float64) {
+	best, bestCorr := unknownKey, 0.0
+	for tonic := range 12 {
+		if c := rotatedCorrelation(ca.average, majorProfile, tonic); c > bestCorr {
+			best, bestCorr = MusicalKey{Tonic: tonic}, c
+		}
+		if c := rotatedCorrelation(ca.average, minorProfile, tonic); c > bestCorr {
+			best, bestCorr = MusicalKey{Tonic: tonic, Minor: true}, c
+		}
+	}
+	if bestCorr < keyMinConf {
+		return unknownKey, bestCorr
+	}
+	return best, bestCorr
+}
+
+// rotatedCorrelation correlates chroma with a profile whose tonic sits on
+// pitch class tonic
+func rotatedCorrelation(chroma, profile [12]float64, tonic int) float64 {
+	var meanC, meanP float64
+	for i := range 12 {
+		meanC += chroma[i]
+		meanP += profile[i]
+	}
+	meanC /= 12
+	meanP /= 12
+
+	var cov, varC, varP float64
+	for i := range 12 {
+		dc := chroma[(i+tonic)%12] - meanC
+		dp := profile[i] - meanP
+		cov += dc * dp
+		varC += dc * dc
+		varP += dp * dp
+	}
+	if varC < 1e-12 {
+		return 0
+	}
+	return cov / math.Sqrt(varC*varP)
+}
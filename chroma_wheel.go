@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	chromaAttack     = 0.6  // how much of a rise shows up per frame
+	chromaDecay      = 0.92 // per frame fall of the displayed chroma
+	chromaInnerRatio = 0.35 // hole in the middle of the wheel, for the key
+)
+
+// ChromaWheelRenderer draws the 12 pitch classes as segments of a wheel, C at
+// the top going clockwise, each segment filling outwards with its energy
+type ChromaWheelRenderer struct {
+	levels     [12]float64
+	noteColors [12]lipgloss.Color
+	key        MusicalKey
+	keyConf    float64
+	canvas     *HalfBlockCanvas
+	cache      *RenderCache
+}
+
+func NewChromaWheelRenderer() *ChromaWheelRenderer {
+	cw := &ChromaWheelRenderer{
+		key:    unknownKey,
+		canvas: NewHalfBlockCanvas(0, 0),
+		cache:  NewRenderCache(),
+	}
+	// Neighbouring notes get neighbouring hues
+	for pc := range cw.noteColors {
+		cw.noteColors[pc] = hueColor(float64(pc) * 30)
+	}
+	return cw
+}
+
+// Update takes the chroma and key of a new analysis frame
+func (cw *ChromaWheelRenderer) Update(frame AudioFrame) {
+	for i, c := range frame.Chroma {
+		if c > cw.levels[i] {
+			cw.levels[i] += (c - cw.levels[i]) * chromaAttack
+		} else {
+			cw.levels[i] *= chromaDecay
+		}
+	}
+	cw.key, cw.keyConf = frame.Key, frame.KeyConfidence
+}
+
+// SetColorScheme does nothing, the note hues stay put so C is always the
+// same color
+func (cw *ChromaWheelRenderer) SetColorScheme(string) {}
+
+// Reset empties the wheel and forgets the key
+func (cw *ChromaWheelRenderer) Reset() {
+	cw.levels = [12]float64{}
+	cw.key, cw.keyConf = unknownKey, 0
+}
+
+func (cw *ChromaWheelRenderer) Render(width, height int) string {
+	if width < 8 || height < 4 {
+		return ""
+	}
+	cw.canvas.Resize(width, height)
+	pw, ph := cw.canvas.PixelSize()
+
+	// Leave room for the note names around the wheel
+	cx, cy := float64(pw-1)/2, float64(ph-1)/2
+	outer := math.Min(cx-3, cy-3)
+	if outer < 4 {
+		outer = math.Min(cx, cy)
+	}
+	inner := outer * chromaInnerRatio
+
+	for y := range ph {
+		for x := range pw {
+			dx, dy := float64(x)-cx, float64(y)-cy
+			r := math.Hypot(dx, dy)
+			if r < inner || r > outer {
+				continue
+			}
+			// 0 at the top, clockwise, each pitch class centred on its angle
+			angle := math.Atan2(dx, -dy)
+			if angle < 0 {
+				angle += 2 * math.Pi
+			}
+			pos := angle/(2*math.Pi)*12 + 0.5
+			pc := int(pos) % 12
+
+			// Gap between the segments
+			if edge := (pos - math.Floor(pos)) * 2 * math.Pi / 12 * r; edge < 0.6 {
+				continue
+			}
+
+			fill := (r - inner) / (outer - inner)
+			if fill <= cw.levels[pc] {
+				cw.canvas.Set(x, y, cw.cache.ApplyGradient(cw.noteColors[pc], 0.3+0.7*cw.levels[pc]))
+			} else {
+				cw.canvas.Set(x, y, cw.cache.BlendColors(cw.noteColors[pc], "#000000", 0.85))
+			}
+		}
+	}
+
+	// Note names just outside the ring, only where they fit
+	if outer > 6 {
+		for pc, name := range pitchClassNames {
+			angle := float64(pc) / 12 * 2 * math.Pi
+			lx := int(math.Round(cx + math.Sin(angle)*(outer+2)))
+			ly := int(math.Round(cy-math.Cos(angle)*(outer+2))) / 2
+			cw.canvas.TextCentered(lx, ly, name, cw.noteColors[pc])
+		}
+	}
+
+	// Key in the middle
+	centre := int(math.Round(cx))
+	row := int(math.Round(cy)) / 2
+	keyColor := lipgloss.Color("#888888")
+	if cw.key.Known() {
+		keyColor = cw.noteColors[cw.key.Tonic]
+	}
+	cw.canvas.TextCentered(centre, row-1, cw.key.String(), keyColor)
+	cw.canvas.TextCentered(centre, row, cw.key.Camelot(), lipgloss.Color("#FFFFFF"))
+	cw.canvas.TextCentered(centre, row+1, fmt.Sprintf("%.0f%%", cw.keyConf*100), lipgloss.Color("#888888"))
+
+	return cw.canvas.String(cw.cache)
+}
+
+// hueColor is a fully saturated color on the color wheel, hue in degrees
+func hueColor(hue float64) lipgloss.Color {
+	h := math.Mod(hue, 360) / 60
+	x := 1 - math.Abs(math.Mod(h, 2)-1)
+	var r, g, b float64
+	switch int(h) {
+	case 0:
+		r, g = 1, x
+	case 1:
+		r, g = x, 1
+	case 2:
+		g, b = 1, x
+	case 3:
+		g, b = x, 1
+	case 4:
+		r, b = x, 1
+	default:
+		r, b = 1, x
+	}
+	return uint8ToHex(uint8(r*255), uint8(g*255), uint8(b*255))
+}
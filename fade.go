@@ -0,0 +1,114 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// dimANSI scales the brightness of every 24-bit and 256-color foreground and
+// background in styled output, 0 black to 1 unchanged. The basic 16 colors
+// are left alone, they have no darker shades to go to.
+func dimANSI(s string, brightness float64) string {
+	if brightness >= 1 {
+		return s
+	}
+	brightness = max(brightness, 0)
+
+	var sb strings.Builder
+	sb.Grow(len(s))
+	for {
+		start := strings.Index(s, "\x1b[")
+		if start < 0 {
+			sb.WriteString(s)
+			return sb.String()
+		}
+		end := strings.IndexByte(s[start:], 'm')
+		if end < 0 {
+			sb.WriteString(s)
+			return sb.String()
+		}
+		end += start
+		sb.WriteString(s[:start])
+		sb.WriteString("\x1b[")
+		sb.WriteString(dimSGR(s[start+2:end], brightness))
+		sb.WriteByte('m')
+		s = s[end+1:]
+	}
+}
+
+// dimSGR rewrites the color parameters of one SGR sequence
+func dimSGR(params string, brightness float64) string {
+	parts := strings.Split(params, ";")
+	for i := 0; i+2 < len(parts); i++ {
+		if parts[i] != "38" && parts[i] != "48" {
+			continue
+		}
+		switch {
+		case parts[i+1] == "2" && i+4 < len(parts):
+			for j := i + 2; j <= i+4; j++ {
+				v, err := strconv.Atoi(parts[j])
+				if err == nil {
+					parts[j] = strconv.Itoa(int(float64(v) * brightness))
+				}
+			}
+			i += 4
+		case parts[i+1] == "5":
+			if n, err := strconv.Atoi(parts[i+2]); err == nil {
+				r, g, b := xterm256ToRGB(n)
+				parts[i+2] = strconv.Itoa(rgbToXterm256(
+					int(float64(r)*brightness), int(float64(g)*brightness), int(float64(b)*brightness)))
+			}
+			i += 2
+		}
+	}
+	return strings.Join(parts, ";")
+}
+
+// cubeLevels are the channel values of the 6x6x6 color cube in the 256 palette
+var cubeLevels = [6]int{0, 95, 135, 175, 215, 255}
+
+func xterm256ToRGB(n int) (int, int, int) {
+	switch {
+	case n < 16:
+		// The basic colors vary by terminal, this is the xterm default
+		base := [16][3]int{
+			{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0}, {0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+			{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0}, {92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+		}
+		c := base[n]
+		return c[0], c[1], c[2]
+	case n < 232:
+		n -= 16
+		return cubeLevels[n/36], cubeLevels[n/6%6], cubeLevels[n%6]
+	default:
+		v := 8 + (n-232)*10
+		return v, v, v
+	}
+}
+
+// rgbToXterm256 picks the closer of the nearest cube color and the nearest gray
+func rgbToXterm256(r, g, b int) int {
+	nearest := func(v int) int {
+		best := 0
+		for i, level := range cubeLevels {
+			if abs(level-v) < abs(cubeLevels[best]-v) {
+				best = i
+			}
+		}
+		return best
+	}
+	ri, gi, bi := nearest(r), nearest(g), nearest(b)
+	cube := 16 + 36*ri + 6*gi + bi
+	cubeDist := sq(cubeLevels[ri]-r) + sq(cubeLevels[gi]-g) + sq(cubeLevels[bi]-b)
+
+	grayIndex := min(max((r+g+b)/3-8+5, 0)/10, 23)
+	gray := 8 + grayIndex*10
+	if sq(gray-r)+sq(gray-g)+sq(gray-b) < cubeDist {
+		return 232 + grayIndex
+	}
+	return cube
+}
+
+func sq(x int) int {
+	return x * x
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	rolloffShare    = 0.85 // share of the spectral energy below the rolloff frequency
+	defaultChaosMap = "flatness:0.4,flux:0.35,centroid:0.25"
+)
+
+// SpectralFeatures are the standard per-frame descriptors of the spectrum
+type SpectralFeatures struct {
+	Centroid float64 // Hz, the spectrum's centre of mass, "brightness"
+	Spread   float64 // Hz, standard deviation around the centroid
+	Flux     float64 // onset envelope, see OnsetDetector
+	Flatness float64 // 0 tonal to 1 noise-like
+	Rolloff  float64 // Hz, below which rolloffShare of the energy sits
+	ZCR      float64 // zero crossings per sample over the last hop
+	RMS      float64 // level of the last hop over both channels, linear
+}
+
+// chaosFeatures maps each feature to 0-1 for the chaos mapping
+var chaosFeatures = map[string]func(SpectralFeatures) float64{
+	"centroid": func(f SpectralFeatures) float64 { return logRange(f.Centroid, 100, 10000) },
+	"spread":   func(f SpectralFeatures) float64 { return math.Min(f.Spread/5000, 1) },
+	"flux":     func(f SpectralFeatures) float64 { return math.Tanh(f.Flux * 5) },
+	"flatness": func(f SpectralFeatures) float64 { return math.Min(f.Flatness*2, 1) },
+	"rolloff":  func(f SpectralFeatures) float64 { return logRange(f.Rolloff, 100, 16000) },
+	"zcr":      func(f SpectralFeatures) float64 { return math.Min(f.ZCR*4, 1) },
+	"rms": func(f SpectralFeatures) float64 {
+		if f.RMS <= 0 {
+			return 0
+		}
+		return math.Max(0, math.Min(1, 1+20*math.Log10(f.RMS)/60))
+	},
+}
+
+// logRange places x between lo and hi on a log axis, clamped to 0-1
+func logRange(x, lo, hi float64) float64 {
+	if x <= lo {
+		return 0
+	}
+	return math.Min(math.Log(x/lo)/math.Log(hi/lo), 1)
+}
+
+// chaosTerm is one weighted feature of a chaos mapping
+type chaosTerm struct {
+	feature string
+	weight  float64
+}
+
+// ChaosMapping turns the features into the 0-1 chaos level. A nil mapping is
+// the original blend of band variance and energy, see calculateChaos.
+type ChaosMapping []chaosTerm
+
+// ParseChaosMapping reads "feature:weight,..." (a bare feature weighs 1), or
+// "legacy" for the original chaos calculation
+func ParseChaosMapping(spec string) (ChaosMapping, error) {
+	spec = strings.TrimSpace(strings.ToLower(spec))
+	if spec == "" {
+		spec = defaultChaosMap
+	}
+	if spec == "legacy" {
+		return nil, nil
+	}
+
+	var mapping ChaosMapping
+	for _, part := range strings.Split(spec, ",") {
+		name, weightText, hasWeight := strings.Cut(strings.TrimSpace(part), ":")
+		if _, ok := chaosFeatures[name]; !ok {
+			names := make([]string, 0, len(chaosFeatures))
+			for n := range chaosFeatures {
+				names = append(names, n)
+			}
+			slices.Sort(names)
+			return nil, fmt.Errorf("unknown chaos feature %q (available: %s, or legacy)", name, strings.Join(names, ", "))
+		}
+		weight := 1.0
+		if hasWeight {
+			w, err := strconv.ParseFloat(weightText, 64)
+			if err != nil || w < 0 {
+				return nil, fmt.Errorf("invalid weight %q for chaos feature %s", weightText, name)
+			}
+			weight = w
+		}
+		mapping = append(mapping, chaosTerm{feature: name, weight: weight})
+	}
+	return mapping, nil
+}
+
+// Chaos is the weighted mean of the mapped features
+func (cm ChaosMapping) Chaos(f SpectralFeatures) float64 {
+	var sum, weights float64
+	for _, term := range cm {
+		sum += chaosFeatures[term.feature](f) * term.weight
+		weights += term.weight
+	}
+	if weights == 0 {
+		return 0
+	}
+	return math.Max(0, math.Min(1, sum/weights))
+}
+
+// spectralFeatures describes the current frame from the FFT of both channels
+// and the samples of the last hop
+func (ap *AudioProcessor) spectralFeatures(flux float64) SpectralFeatures {
+	features := SpectralFeatures{Flux: flux}
+
+	ringSize := len(ap.ringLeft)
+	var sum float64
+	crossings := 0
+	prev := 0.0
+	for i := ap.hopSize; i >= 1; i-- {
+		pos := (ap.ringPos - i + ringSize) % ringSize
+		left, right := ap.ringLeft[pos], ap.ringRight[pos]
+		sum += left*left + right*right
+		mono := (left + right) / 2
+		if i < ap.hopSize && (mono >= 0) != (prev >= 0) {
+			crossings++
+		}
+		prev = mono
+	}
+	features.RMS = math.Sqrt(sum / float64(2*ap.hopSize))
+	features.ZCR = float64(crossings) / float64(max(ap.hopSize-1, 1))
+
+	// Magnitude spectrum of the channel sum, DC left out
+	binWidth := float64(ap.sampleRate) / float64(ap.fftSize)
+	var total, weighted, power, logSum float64
+	bins := len(ap.coeffsLeft) - 1
+	for j := 1; j <= bins; j++ {
+		mag := cmplx.Abs(ap.coeffsLeft[j]+ap.coeffsRight[j]) / 2
+		ap.magnitudes[j] = mag
+		total += mag
+		weighted += mag * float64(j) * binWidth
+		power += mag * mag
+		logSum += math.Log(mag*mag + 1e-12)
+	}
+	if total < 1e-9 {
+		return features
+	}
+
+	features.Centroid = weighted / total
+	var spread, cumulative float64
+	rolloffFound := false
+	for j := 1; j <= bins; j++ {
+		freq := float64(j) * binWidth
+		mag := ap.magnitudes[j]
+		spread += (freq - features.Centroid) * (freq - features.Centroid) * mag
+		cumulative += mag * mag
+		if !rolloffFound && cumulative >= rolloffShare*power {
+			features.Rolloff = freq
+			rolloffFound = true
+		}
+	}
+	features.Spread = math.Sqrt(spread / total)
+	features.Flatness = math.Exp(logSum/float64(bins)) / (power / float64(bins))
+	return features
+}
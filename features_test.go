@@ -0,0 +1,62 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseChaosMapping(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    ChaosMapping
+		wantErr bool
+	}{
+		{"", ChaosMapping{{"flatness", 0.4}, {"flux", 0.35}, {"centroid", 0.25}}, false},
+		{"legacy", nil, false},
+		{" LEGACY ", nil, false},
+		{"flux", ChaosMapping{{"flux", 1}}, false},
+		{"rms:2, zcr:0.5", ChaosMapping{{"rms", 2}, {"zcr", 0.5}}, false},
+		{"flux:0", ChaosMapping{{"flux", 0}}, false},
+		{"loudness", nil, true},
+		{"flux:", nil, true},
+		{"flux:-1", nil, true},
+		{"flux:abc", nil, true},
+		{"flux,,centroid", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseChaosMapping(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseChaosMapping(%q): error %v, want error %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("ParseChaosMapping(%q) = %v, want %v", tt.spec, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("ParseChaosMapping(%q) = %v, want %v", tt.spec, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestChaosMappingRange(t *testing.T) {
+	mapping, err := ParseChaosMapping("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []SpectralFeatures{
+		{},
+		{Centroid: 50, Flatness: 0, Flux: 0},
+		{Centroid: 20000, Flatness: 1, Flux: 10, Rolloff: 20000, ZCR: 1, RMS: 1},
+	} {
+		if chaos := mapping.Chaos(f); chaos < 0 || chaos > 1 || math.IsNaN(chaos) {
+			t.Errorf("Chaos(%+v) = %v, want 0-1", f, chaos)
+		}
+	}
+	if chaos := (ChaosMapping{{"flux", 0}}).Chaos(SpectralFeatures{Flux: 1}); chaos != 0 {
+		t.Errorf("all weights zero gives %v, want 0", chaos)
+	}
+}
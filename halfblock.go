@@ -0,0 +1,136 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// halfBlockCell is what ends up in one terminal cell after combining its two
+// pixels, or a text overlay
+type halfBlockCell struct {
+	fg, bg lipgloss.Color
+	char   rune
+}
+
+// HalfBlockCanvas is a pixel surface with two vertically stacked pixels per
+// terminal cell (drawn with ▀/▄), so pixels come out roughly square. Text can
+// be placed on top of the pixels cell by cell.
+type HalfBlockCanvas struct {
+	width, height int              // in cells
+	pixels        []lipgloss.Color // width x 2*height, "" is empty
+	text          []rune           // per cell overlay, 0 for none
+	textColors    []lipgloss.Color
+}
+
+func NewHalfBlockCanvas(width, height int) *HalfBlockCanvas {
+	hc := &HalfBlockCanvas{}
+	hc.Resize(width, height)
+	return hc
+}
+
+// Resize changes the canvas size in cells and clears it
+func (hc *HalfBlockCanvas) Resize(width, height int) {
+	width, height = max(width, 0), max(height, 0)
+	if width != hc.width || height != hc.height {
+		hc.width, hc.height = width, height
+		hc.pixels = make([]lipgloss.Color, width*height*2)
+		hc.text = make([]rune, width*height)
+		hc.textColors = make([]lipgloss.Color, width*height)
+	}
+	hc.Clear()
+}
+
+func (hc *HalfBlockCanvas) Clear() {
+	for i := range hc.pixels {
+		hc.pixels[i] = ""
+	}
+	for i := range hc.text {
+		hc.text[i] = 0
+	}
+}
+
+// PixelSize is the canvas size in pixels
+func (hc *HalfBlockCanvas) PixelSize() (int, int) {
+	return hc.width, hc.height * 2
+}
+
+// Set colors one pixel, pixels outside the canvas are ignored
+func (hc *HalfBlockCanvas) Set(x, y int, color lipgloss.Color) {
+	if x < 0 || y < 0 || x >= hc.width || y >= hc.height*2 {
+		return
+	}
+	hc.pixels[y*hc.width+x] = color
+}
+
+// Text writes a string starting at cell x, y, clipped to the canvas
+func (hc *HalfBlockCanvas) Text(x, y int, s string, color lipgloss.Color) {
+	if y < 0 || y >= hc.height {
+		return
+	}
+	for _, r := range s {
+		if x >= 0 && x < hc.width {
+			hc.text[y*hc.width+x] = r
+			hc.textColors[y*hc.width+x] = color
+		}
+		x++
+	}
+}
+
+// TextCentered writes a string centred on cell x
+func (hc *HalfBlockCanvas) TextCentered(x, y int, s string, color lipgloss.Color) {
+	hc.Text(x-len([]rune(s))/2, y, s, color)
+}
+
+func (hc *HalfBlockCanvas) cell(x, y int) halfBlockCell {
+	i := y*hc.width + x
+	if hc.text[i] != 0 {
+		return halfBlockCell{fg: hc.textColors[i], char: hc.text[i]}
+	}
+	top := hc.pixels[2*y*hc.width+x]
+	bottom := hc.pixels[(2*y+1)*hc.width+x]
+	switch {
+	case top == "" && bottom == "":
+		return halfBlockCell{char: ' '}
+	case bottom == "":
+		return halfBlockCell{fg: top, char: '▀'}
+	case top == "":
+		return halfBlockCell{fg: bottom, char: '▄'}
+	default:
+		return halfBlockCell{fg: top, bg: bottom, char: '▀'}
+	}
+}
+
+// String renders the canvas, grouping runs of the same colors into one style
+func (hc *HalfBlockCanvas) String(cache *RenderCache) string {
+	var sb strings.Builder
+	for y := range hc.height {
+		x := 0
+		for x < hc.width {
+			c := hc.cell(x, y)
+			if c.char == ' ' && c.bg == "" {
+				sb.WriteByte(' ')
+				x++
+				continue
+			}
+			var run strings.Builder
+			for x < hc.width {
+				next := hc.cell(x, y)
+				if next.fg != c.fg || next.bg != c.bg || next.char == ' ' {
+					break
+				}
+				run.WriteRune(next.char)
+				x++
+			}
+			if c.bg == "" {
+				sb.WriteString(cache.GetStyle(c.fg).Render(run.String()))
+			} else {
+				sb.WriteString(cache.GetStyleFGBG(c.fg, c.bg).Render(run.String()))
+			}
+		}
+		if y < hc.height-1 {
+			sb.WriteByte('\n')
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"math"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Dark to bright, the aurora runs through these
+var (
+	idleDefaultColors = []lipgloss.Color{"#1A0033", "#3A0CA3", "#4361EE", "#00B4D8", "#00FFFF"}
+	idleRetroColors   = []lipgloss.Color{"#1A0A00", "#6A040F", "#D00000", "#F48C06", "#FFD400"}
+)
+
+// IdleRenderer is the screensaver shown while nothing plays: slow aurora
+// curtains drawn from the noise generator alone
+type IdleRenderer struct {
+	noiseGen *NoiseGenerator
+	palette  []lipgloss.Color
+	canvas   *HalfBlockCanvas
+	cache    *RenderCache
+
+	// The last frame, audio frames keep calling View while idle and the
+	// picture only changes on ticks
+	lastOutput string
+	lastTime   float64
+	lastFade   float64
+	lastWidth  int
+	lastHeight int
+}
+
+func NewIdleRenderer(noiseGen *NoiseGenerator) *IdleRenderer {
+	return &IdleRenderer{
+		noiseGen: noiseGen,
+		palette:  spreadPalette(idleDefaultColors, 32),
+		canvas:   NewHalfBlockCanvas(0, 0),
+		cache:    NewRenderCache(),
+	}
+}
+
+func (ir *IdleRenderer) SetColorScheme(scheme string) {
+	if scheme == "retro" {
+		ir.palette = spreadPalette(idleRetroColors, 32)
+	} else {
+		ir.palette = spreadPalette(idleDefaultColors, 32)
+	}
+	ir.lastOutput = ""
+}
+
+// Render draws the animation at the given brightness (0-1), used to fade it
+// in and out
+func (ir *IdleRenderer) Render(width, height int, fade float64) string {
+	t := ir.noiseGen.time
+	if ir.lastOutput != "" && t == ir.lastTime && fade == ir.lastFade && width == ir.lastWidth && height == ir.lastHeight {
+		return ir.lastOutput
+	}
+
+	ir.canvas.Resize(width, height)
+	pw, ph := ir.canvas.PixelSize()
+	for x := range pw {
+		// Each column's curtain hangs from a noisy height and sways over time
+		fx := float64(x) / float64(max(pw, 1))
+		top := 0.25 + 0.2*ir.noiseGen.GenerateFBM(fx*3, t*0.15, 3, 0.5)
+		glow := 0.5 + 0.5*ir.noiseGen.GenerateFBM(fx*6+100, t*0.25, 2, 0.5)
+		for y := range ph {
+			fy := float64(y) / float64(max(ph, 1))
+			// Bright at the curtain's edge, fading towards the bottom
+			d := fy - top
+			if d < 0 {
+				d = -d * 4 // sharper above the edge
+			}
+			intensity := math.Exp(-d*4) * glow
+			intensity *= 0.6 + 0.4*ir.noiseGen.Generate(fx*12, fy*3+t*0.4)
+			intensity = math.Max(0, math.Min(1, intensity)) * fade
+			if intensity < 0.08 {
+				continue
+			}
+			ir.canvas.Set(x, y, ir.palette[int(intensity*float64(len(ir.palette)-1))])
+		}
+	}
+
+	ir.lastOutput = ir.canvas.String(ir.cache)
+	ir.lastTime, ir.lastFade, ir.lastWidth, ir.lastHeight = t, fade, width, height
+	return ir.lastOutput
+}
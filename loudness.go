@@ -0,0 +1,254 @@
+package main
+
+import (
+	"math"
+	"time"
+)
+
+// EBU R128 / ITU-R BS.1770 loudness metering
+const (
+	loudnessBlock      = 100 * time.Millisecond // sub-block, gating blocks overlap by 75%
+	momentaryBlocks    = 4                      // 400ms
+	shortTermBlocks    = 30                     // 3s
+	absoluteGate       = -70.0                  // LUFS
+	relativeGate       = -10.0                  // LU below the ungated integrated loudness
+	loudnessHistMax    = 10.0                   // top of the integrated histogram, LUFS
+	loudnessHistStep   = 0.1                    // LU per histogram bin
+	truePeakOversample = 4
+	truePeakTaps       = 12 // per phase
+)
+
+// LoudnessReading is one set of meter values. Loudness is in LUFS and true
+// peak in dBTP, all -Inf until there is signal.
+type LoudnessReading struct {
+	Momentary  float64
+	ShortTerm  float64
+	Integrated float64
+	TruePeak   float64 // maximum since start
+}
+
+// biquad is a direct form I second order section
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+	x1, x2, y1, y2     float64
+}
+
+func (bq *biquad) process(x float64) float64 {
+	y := bq.b0*x + bq.b1*bq.x1 + bq.b2*bq.x2 - bq.a1*bq.y1 - bq.a2*bq.y2
+	bq.x2, bq.x1 = bq.x1, x
+	bq.y2, bq.y1 = bq.y1, y
+	return y
+}
+
+// kWeighting returns the BS.1770 pre-filter (high shelf) and RLB high-pass,
+// recalculated for any sample rate
+func kWeighting(sampleRate float64) (biquad, biquad) {
+	f0, gain, q := 1681.974450955533, 3.999843853973347, 0.7071752369554196
+	k := math.Tan(math.Pi * f0 / sampleRate)
+	vh := math.Pow(10, gain/20)
+	vb := math.Pow(vh, 0.4996667741545416)
+	a0 := 1 + k/q + k*k
+	shelf := biquad{
+		b0: (vh + vb*k/q + k*k) / a0,
+		b1: 2 * (k*k - vh) / a0,
+		b2: (vh - vb*k/q + k*k) / a0,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+
+	f0, q = 38.13547087602444, 0.5003270373238773
+	k = math.Tan(math.Pi * f0 / sampleRate)
+	a0 = 1 + k/q + k*k
+	highpass := biquad{
+		b0: 1, b1: -2, b2: 1,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+	return shelf, highpass
+}
+
+// truePeakFilter builds the polyphase windowed-sinc interpolator used to
+// estimate inter-sample peaks
+func truePeakFilter() [truePeakOversample][truePeakTaps]float64 {
+	var phases [truePeakOversample][truePeakTaps]float64
+	length := truePeakOversample * truePeakTaps
+	centre := float64(length-1) / 2
+	for n := range length {
+		t := (float64(n) - centre) / truePeakOversample
+		sinc := 1.0
+		if t != 0 {
+			sinc = math.Sin(math.Pi*t) / (math.Pi * t)
+		}
+		window := 0.5 - 0.5*math.Cos(2*math.Pi*(float64(n)+0.5)/float64(length))
+		phases[n%truePeakOversample][n/truePeakOversample] = sinc * window
+	}
+	// Unity gain per phase
+	for p := range phases {
+		var sum float64
+		for _, h := range phases[p] {
+			sum += h
+		}
+		for i := range phases[p] {
+			phases[p][i] /= sum
+		}
+	}
+	return phases
+}
+
+// loudnessChannel is the per-channel filter and oversampling state
+type loudnessChannel struct {
+	shelf, highpass biquad
+	history         [truePeakTaps]float64
+	historyPos      int
+}
+
+// LoudnessMeter measures momentary, short-term and integrated loudness plus
+// true peak of up to two channels, following EBU R128
+type LoudnessMeter struct {
+	channels     []loudnessChannel
+	inChannels   int
+	blockSamples int
+	blockFill    int
+	blockSum     float64   // weighted sum of squares of the current sub-block
+	blocks       []float64 // mean square of the last shortTermBlocks sub-blocks
+	blockPos     int
+	blockCount   int
+	phases       [truePeakOversample][truePeakTaps]float64
+
+	// Histogram of gating block loudness for the integrated value, keeps
+	// memory constant however long the session runs
+	histEnergy []float64
+	histCount  []int
+
+	reading LoudnessReading
+}
+
+func NewLoudnessMeter(sampleRate, channels int) *LoudnessMeter {
+	measured := min(channels, 2)
+	lm := &LoudnessMeter{
+		channels:     make([]loudnessChannel, measured),
+		inChannels:   channels,
+		blockSamples: int(float64(sampleRate) * loudnessBlock.Seconds()),
+		blocks:       make([]float64, shortTermBlocks),
+		phases:       truePeakFilter(),
+		histEnergy:   make([]float64, int((loudnessHistMax-absoluteGate)/loudnessHistStep)+1),
+		histCount:    make([]int, int((loudnessHistMax-absoluteGate)/loudnessHistStep)+1),
+		reading: LoudnessReading{
+			Momentary:  math.Inf(-1),
+			ShortTerm:  math.Inf(-1),
+			Integrated: math.Inf(-1),
+			TruePeak:   math.Inf(-1),
+		},
+	}
+	for i := range lm.channels {
+		lm.channels[i].shelf, lm.channels[i].highpass = kWeighting(float64(sampleRate))
+	}
+	return lm
+}
+
+// Process meters a buffer of interleaved samples. Channels past the second
+// are ignored, like in the FFT.
+func (lm *LoudnessMeter) Process(buffer []float32) {
+	peak := 0.0
+	for i := 0; i+lm.inChannels <= len(buffer); i += lm.inChannels {
+		for c := range lm.channels {
+			ch := &lm.channels[c]
+			x := float64(buffer[i+c])
+
+			// K-weighted power, both channels weigh 1.0
+			y := ch.highpass.process(ch.shelf.process(x))
+			lm.blockSum += y * y
+
+			// True peak on the oversampled signal
+			ch.history[ch.historyPos] = x
+			for p := range lm.phases {
+				var sum float64
+				for k, h := range lm.phases[p] {
+					sum += h * ch.history[(ch.historyPos-k+truePeakTaps)%truePeakTaps]
+				}
+				peak = math.Max(peak, math.Abs(sum))
+			}
+			ch.historyPos = (ch.historyPos + 1) % truePeakTaps
+		}
+
+		lm.blockFill++
+		if lm.blockFill == lm.blockSamples {
+			lm.finishBlock()
+		}
+	}
+
+	if peak > 0 {
+		lm.reading.TruePeak = math.Max(lm.reading.TruePeak, 20*math.Log10(peak))
+	}
+}
+
+// Reading returns the current meter values
+func (lm *LoudnessMeter) Reading() LoudnessReading {
+	return lm.reading
+}
+
+func (lm *LoudnessMeter) finishBlock() {
+	lm.blocks[lm.blockPos] = lm.blockSum / float64(lm.blockSamples)
+	lm.blockPos = (lm.blockPos + 1) % len(lm.blocks)
+	lm.blockCount++
+	lm.blockSum, lm.blockFill = 0, 0
+
+	lm.reading.Momentary = lm.windowLoudness(momentaryBlocks)
+	lm.reading.ShortTerm = lm.windowLoudness(shortTermBlocks)
+
+	// Every sub-block completes a new 400ms gating block
+	if lm.blockCount >= momentaryBlocks {
+		if l := lm.reading.Momentary; l > absoluteGate && l < loudnessHistMax {
+			bin := int((l - absoluteGate) / loudnessHistStep)
+			lm.histEnergy[bin] += loudnessToEnergy(l)
+			lm.histCount[bin]++
+			lm.reading.Integrated = lm.integrated()
+		}
+	}
+}
+
+// windowLoudness is the loudness over the last n sub-blocks (or fewer at the start)
+func (lm *LoudnessMeter) windowLoudness(n int) float64 {
+	n = min(n, lm.blockCount, len(lm.blocks))
+	var sum float64
+	for i := 1; i <= n; i++ {
+		sum += lm.blocks[(lm.blockPos-i+len(lm.blocks))%len(lm.blocks)]
+	}
+	return energyToLoudness(sum / float64(n))
+}
+
+// integrated applies the relative gate to the absolute-gated histogram
+func (lm *LoudnessMeter) integrated() float64 {
+	var energy float64
+	var count int
+	for i := range lm.histCount {
+		energy += lm.histEnergy[i]
+		count += lm.histCount[i]
+	}
+	if count == 0 {
+		return math.Inf(-1)
+	}
+
+	gate := energyToLoudness(energy/float64(count)) + relativeGate
+	first := max(int(math.Ceil((gate-absoluteGate)/loudnessHistStep)), 0)
+	energy, count = 0, 0
+	for i := first; i < len(lm.histCount); i++ {
+		energy += lm.histEnergy[i]
+		count += lm.histCount[i]
+	}
+	if count == 0 {
+		return math.Inf(-1)
+	}
+	return energyToLoudness(energy / float64(count))
+}
+
+func energyToLoudness(e float64) float64 {
+	if e <= 0 {
+		return math.Inf(-1)
+	}
+	return -0.691 + 10*math.Log10(e)
+}
+
+func loudnessToEnergy(l float64) float64 {
+	return math.Pow(10, (l+0.691)/10)
+}
@@ -7,9 +7,7 @@ import (
 	"image/png"
 	"log"
 	"os"
-	"os/exec"
 	"os/signal"
-	"runtime"
 	"strings"
 	"sync"
 	"syscall"
@@ -99,10 +97,27 @@ func detectAudioSetup() error {
 // Include metadata from OS media session
 // (populated on platforms that support it)
 type AudioFrame struct {
-	Bands      [9]float64
-	ChaosLevel float64
-	Timestamp  time.Time
-	Metadata   AudioMetadata
+	Bands           []float64 // one value per analysis band, 0-1
+	ChaosLevel      float64   // 0-1, mapped from Features by --chaos
+	Features        SpectralFeatures
+	Beat            bool      // an onset strong enough to count as a beat
+	OnsetStrength   float64   // 0-1, 0.5 is right at the beat threshold
+	BandOnsets      []float64 // per-band onset strength, 0-1
+	BPM             float64   // estimated tempo, 0 while unknown
+	TempoConfidence float64   // 0-1
+	Loudness        LoudnessReading
+	Gain            float64 // linear gain applied to Bands (AGC and sensitivity)
+	Stereo          StereoImage
+	Waveform        Waveform
+	Spectrum        []float32   // dBFS on a log frequency axis, see logSpectrum
+	Chroma          [12]float64 // energy per pitch class (C first), loudest at 1
+	Key             MusicalKey
+	KeyConfidence   float64 // correlation with the key profile, 0-1
+	Pitch           float64 // fundamental in Hz for monophonic input, 0 if unclear
+	PitchClarity    float64 // 0-1
+	Idle            bool    // the input has been silent for a while
+	Timestamp       time.Time
+	Metadata        AudioMetadata
 }
 
 type FrequencyBand struct {
@@ -113,6 +128,7 @@ type FrequencyBand struct {
 	Color   lipgloss.Color
 }
 
+// The classic layout, see bands.go for the generated ones
 var frequencyBands = []FrequencyBand{
 	{Name: "Sub-Bass", MinFreq: 20, MaxFreq: 60},       // Deep low-end rumble
 	{Name: "Bass", MinFreq: 60, MaxFreq: 250},          // Kick drum, bass guitar fundamentals
@@ -127,9 +143,49 @@ var frequencyBands = []FrequencyBand{
 
 var (
 	fps         = flag.Int("fps", 60, "Frames per second(10-120)")
-	sensitivity = flag.Float64("sensitivity", 1.0, "Audio sensitivity multiplier(0.5-2.0)")
+	idleFPS     = flag.Int("idle-fps", 15, "Frames per second while idle (1-60)")
+	idleAfter   = flag.Duration("idle-after", 10*time.Second, "Silence before switching to the idle animation (0 = never)")
+	idleLevel   = flag.Float64("idle-threshold", -60, "Level in dBFS below which the input counts as silent")
+	sensitivity = flag.Float64("sensitivity", 1.0, "Audio sensitivity multiplier, applied after the AGC (0.5-2.0)")
 	colorScheme = flag.String("colors", "vibrant", "Color scheme ( vibrant, retro, pastel, mono)")
-	deviceName  = flag.String("device", "", "Audio device name (empty = auto)")
+	deviceName  = flag.String("device", "", "Exact capture device: PortAudio index/name or PulseAudio source (empty = auto)")
+	backendName = flag.String("backend", "", "Capture backend (parec, portaudio, file, stdin, synthetic, replay; empty = platform default)")
+	inputFile   = flag.String("file", "", "WAV/FLAC file to play and visualize (implies --backend file)")
+	muteFile    = flag.Bool("mute", false, "Visualize --file without playing it out loud")
+	fifoPath    = flag.String("fifo", "", "Named pipe to read raw PCM from, e.g. MPD fifo output (implies --backend stdin)")
+	pcmFormat   = flag.String("pcm-format", "s16le", "Raw PCM encoding for stdin/fifo (s16le, s32le, f32le)")
+	signalKind  = flag.String("signal", "", "Synthetic test signal: sweep, chord, pink, white, clicks, silence (implies --backend synthetic)")
+	clickBPM    = flag.Float64("click-bpm", 120, "Tempo of the synthetic click track")
+	captureApp  = flag.String("app", "", "Capture only this application's audio, e.g. spotify, or 'playing' for the active MPRIS player (parec)")
+	captureRate = flag.Int("rate", 44100, "Capture sample rate in Hz")
+	channelsArg = flag.Int("channels", 0, "Capture channel count (0 = backend default)")
+	recordPath  = flag.String("record", "", "Record raw samples and analyzed frames to this file")
+	replayPath  = flag.String("replay", "", "Replay a session recorded with --record (implies --backend replay)")
+	windowName  = flag.String("window", "hann", "FFT analysis window (hann, hamming, blackman-harris, none)")
+	analyzerArg = flag.String("analyzer", "fft", "Band analyzer: fft, mel (triangular filterbank) or cqt (constant-Q, more CPU)")
+	fftSize     = flag.Int("fft-size", 4096, "Samples per FFT frame")
+	hopSize     = flag.Int("hop", 512, "Frames between analyses, smaller means faster band updates")
+	bandCount   = flag.Int("bands", 0, "Number of bands for the log and mel layouts (4-128, 0 = layout default)")
+	bandLayout  = flag.String("band-layout", "", "Band layout: classic, log, octave, third-octave, mel, custom (empty = classic, or log with --bands)")
+	bandEdges   = flag.String("band-edges", "", "Comma separated band edges in Hz for the custom layout, e.g. 20,60,250,2000,6000,20000")
+	tempoSync   = flag.Bool("tempo-sync", false, "Scale the animation speed with the detected tempo")
+	showMeter   = flag.Bool("meter", false, "Show the EBU R128 loudness meter next to the metadata (toggle with m)")
+	agcMode     = flag.String("agc", "global", "Automatic gain control: global, per-band, off")
+	agcTarget   = flag.Float64("agc-target", 0.7, "Level (0-1) the AGC keeps the bands at")
+	agcAttack   = flag.Duration("agc-attack", 50*time.Millisecond, "How fast the AGC turns down on louder audio")
+	agcRelease  = flag.Duration("agc-release", 3*time.Second, "How fast the AGC turns back up on quieter audio")
+	chaosSpec   = flag.String("chaos", defaultChaosMap, "Features driving the chaos level as feature:weight,... (centroid, spread, flux, flatness, rolloff, zcr, rms) or legacy")
+	tunerA4     = flag.Float64("a4", 440, "Reference pitch of A4 in Hz for the tuner view")
+	modeName    = flag.String("mode", "beams", "Visualization to start with: "+strings.Join(visualizerNames(), ", ")+" (cycle with TAB)")
+	barWidth    = flag.Int("bar-width", 0, "Width of each bar in the bars view, in cells (0 = fill the screen)")
+	barGap      = flag.Int("bar-gap", 1, "Cells between bars in the bars view")
+	peakGravity = flag.Float64("gravity", defaultBarGravity, "How fast the peak caps fall in the bars view, screen heights per second²")
+	phosphor    = flag.Int("phosphor", 0, "Older traces the scope view keeps fading on screen (0-4)")
+	fallDir     = flag.String("waterfall-dir", "vertical", "Waterfall scrolling: vertical (newest on top) or horizontal (newest on the right)")
+	fallSpan    = flag.Duration("waterfall-span", defaultWaterfallSpan, "Time the waterfall view covers")
+	dbFloor     = flag.Float64("db-floor", defaultDBFloor, "Waterfall level in dBFS shown as darkest, quieter is blank")
+	dbCeiling   = flag.Float64("db-ceiling", defaultDBCeiling, "Waterfall level in dBFS shown as brightest")
+	cellAspect  = flag.Float64("cell-aspect", defaultCellAspect, "Height over width of a terminal cell, keeps the radial view round")
 )
 
 func generateWaveform(inputPath, outputPath string) error {
@@ -175,194 +231,6 @@ func generateWaveform(inputPath, outputPath string) error {
 	return nil
 }
 
-func selectCaptureDevice() (*portaudio.DeviceInfo, error) {
-	devices, err := portaudio.Devices()
-	if err != nil {
-		return nil, fmt.Errorf("failed to enumerate devices: %w", err)
-	}
-
-	// Get the default sink name from PulseAudio/PipeWire on Linux
-	var defaultSinkMonitor string
-	if runtime.GOOS == "linux" {
-		cmd := exec.Command("pactl", "info")
-		if output, err := cmd.Output(); err == nil {
-			// Extract default sink name
-			lines := strings.Split(string(output), "\n")
-			for _, line := range lines {
-				if strings.Contains(line, "Default Sink:") {
-					parts := strings.Split(line, ":")
-					if len(parts) == 2 {
-						sinkName := strings.TrimSpace(parts[1])
-						defaultSinkMonitor = sinkName + ".monitor"
-						log.Printf("Found default sink: %s (looking for monitor: %s)", sinkName, defaultSinkMonitor)
-						break
-					}
-				}
-			}
-		}
-	}
-
-	// First pass: Try to find the monitor of the default sink
-	// Extract key parts of the sink name to match against PortAudio device names
-	if defaultSinkMonitor != "" {
-		// The monitor name often differs slightly from PulseAudio/PipeWire naming
-		// Extract meaningful parts (e.g., "SteelSeries_Arctis" from full name)
-		sinkParts := strings.FieldsFunc(defaultSinkMonitor, func(r rune) bool {
-			return r == '.' || r == '-' || r == '_'
-		})
-
-		for _, device := range devices {
-			if device.MaxInputChannels == 0 {
-				continue
-			}
-			name := strings.ToLower(device.Name)
-
-			// Try to match multiple parts of the sink name
-			matchCount := 0
-			for _, part := range sinkParts {
-				if len(part) > 3 && strings.Contains(name, strings.ToLower(part)) {
-					matchCount++
-				}
-			}
-
-			// If we match at least 2 significant parts, it's likely our device
-			if matchCount >= 2 {
-				log.Printf("✓ Selected monitor of default sink: %s (matched %d parts of %s)",
-					device.Name, matchCount, defaultSinkMonitor)
-				return device, nil
-			}
-		}
-	}
-
-	// Second pass: Try to find any monitor device (more specific than loopback)
-	for _, device := range devices {
-		if device.MaxInputChannels == 0 {
-			continue
-		}
-
-		name := strings.ToLower(device.Name)
-
-		// Skip JACK devices that cause crashes
-		if strings.Contains(name, "jack") {
-			continue
-		}
-
-		// Prioritize monitor devices
-		if strings.Contains(name, "monitor") {
-			log.Printf("✓ Selected monitor device: %s", device.Name)
-			return device, nil
-		}
-	}
-
-	// Third pass: Try loopback devices (less reliable)
-	for _, device := range devices {
-		if device.MaxInputChannels == 0 {
-			continue // Skip output-only devices
-		}
-
-		name := strings.ToLower(device.Name)
-
-		// Skip JACK devices that cause crashes
-		if strings.Contains(name, "jack") {
-			continue
-		}
-
-		// macOS: BlackHole driver
-		// Windows: Stereo Mix, loopback
-		if strings.Contains(name, "blackhole") ||
-			strings.Contains(name, "stereo mix") ||
-			strings.Contains(name, "loopback") ||
-			strings.Contains(name, "what u hear") { // Creative Sound Blaster
-			log.Printf("✓ Selected audio device: %s", device.Name)
-			return device, nil
-		}
-	}
-
-	// Fall back to default input device
-	defaultInput, err := portaudio.DefaultInputDevice()
-	if err != nil {
-		return nil, fmt.Errorf("no suitable audio device found: %w", err)
-	}
-
-	log.Printf("⚠ Using default input device: %s (may not capture system audio)", defaultInput.Name)
-	return defaultInput, nil
-}
-
-func startPortAudio(sampleRate int, framesPerBuf int, inChannels int) (chan []float32, func() error, error) {
-	// Force ALSA to avoid JACK backend issues on Linux
-	os.Setenv("PA_ALSA_PLUGHW", "1")
-	os.Setenv("SDL_AUDIODRIVER", "alsa")
-
-	// select device - but we'll use OpenDefaultStream for now to test
-	device, err := selectCaptureDevice()
-	if err != nil {
-		return nil, nil, fmt.Errorf("device selection failed: %w", err)
-	}
-
-	LogInfo("Selected device: %s", device.Name)
-
-	// Create Int32 buffer for reading audio
-	bufferInt32 := make([]int32, framesPerBuf)
-
-	// Use OpenDefaultStream with the buffer - simpler and more reliable
-	stream, err := portaudio.OpenDefaultStream(inChannels, 0, float64(sampleRate), len(bufferInt32), bufferInt32)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to open stream: %w", err)
-	}
-
-	if err := stream.Start(); err != nil {
-		stream.Close()
-		return nil, nil, fmt.Errorf("failed to start stream: %w", err)
-	}
-
-	ch := make(chan []float32, 8)
-	done := make(chan struct{})
-	doneDone := make(chan struct{})
-
-	// reader loop
-	go func() {
-		defer func() {
-			stream.Stop()
-			stream.Close()
-			close(ch)
-			close(doneDone)
-		}()
-
-		for {
-			select {
-			case <-done:
-				return
-			default:
-				// Read audio data
-				if err := stream.Read(); err != nil {
-					LogError("Stream read error: %v", err)
-					return
-				}
-
-				// Convert Int32 to Float32 normalized to [-1.0, 1.0]
-				buf := make([]float32, len(bufferInt32))
-				for i, sample := range bufferInt32 {
-					buf[i] = float32(sample) / 2147483648.0 // 2^31
-				}
-
-				select {
-				case ch <- buf:
-				default:
-					// Channel full, skip
-				}
-			}
-		}
-	}()
-
-	stop := func() error {
-		close(done)
-		<-doneDone
-		return nil
-	}
-
-	return ch, stop, nil
-}
-
 func main() {
 	// Write to stderr immediately so we know the binary runs
 	fmt.Fprintln(os.Stderr, "[DEBUG] Binary starting...")
@@ -398,53 +266,193 @@ func main() {
 		}
 	}()
 
+	if len(os.Args) > 1 && os.Args[1] == "devices" {
+		LogInfo("Listing capture devices")
+		if err := runListDevices(); err != nil {
+			LogError("Device listing failed: %v", err)
+			fmt.Fprintf(os.Stderr, "[ERROR] %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	fmt.Fprintln(os.Stderr, "[DEBUG] Calling runVisualizer()")
 	runVisualizer()
 	LogInfo("Application exiting normally")
 	fmt.Fprintln(os.Stderr, "[DEBUG] Exiting normally")
 }
 
+// runListDevices implements `termulizer devices`
+func runListDevices() error {
+	// A broken PortAudio setup shouldn't hide the PulseAudio sources
+	if err := portaudio.Initialize(); err != nil {
+		LogError("Failed to initialize PortAudio: %v", err)
+	} else {
+		defer portaudio.Terminate()
+	}
+
+	return printCaptureDevices(os.Stdout)
+}
+
 func runVisualizer() {
 	fmt.Fprintln(os.Stderr, "[DEBUG] runVisualizer() started")
 
-	// init port audio
-	const sampleRate = 44100
-	const framesPerBuffer = 2048
-	const channels = 1
-
 	flag.Parse()
+	if *hopSize < 1 || *hopSize > *fftSize {
+		log.Fatalf("--hop must be between 1 and --fft-size (%d), got %d", *fftSize, *hopSize)
+	}
+	if *tunerA4 < 400 || *tunerA4 > 480 {
+		log.Fatalf("--a4 must be between 400 and 480 Hz, got %g", *tunerA4)
+	}
+	if *fps < 10 || *fps > 120 {
+		log.Fatalf("--fps must be between 10 and 120, got %d", *fps)
+	}
+	if *idleFPS < 1 || *idleFPS > 60 {
+		log.Fatalf("--idle-fps must be between 1 and 60, got %d", *idleFPS)
+	}
+	if *barWidth < 0 || *barGap < 0 {
+		log.Fatalf("--bar-width and --bar-gap can't be negative, got %d and %d", *barWidth, *barGap)
+	}
+	if *peakGravity <= 0 {
+		log.Fatalf("--gravity must be positive, got %g", *peakGravity)
+	}
+	if *phosphor < 0 || *phosphor > 4 {
+		log.Fatalf("--phosphor must be between 0 and 4, got %d", *phosphor)
+	}
+	if *fallDir != "vertical" && *fallDir != "horizontal" {
+		log.Fatalf("--waterfall-dir must be vertical or horizontal, got %q", *fallDir)
+	}
+	if *fallSpan <= 0 || *fallSpan > maxWaterfallSpan {
+		log.Fatalf("--waterfall-span must be positive and at most %s, got %s", maxWaterfallSpan, *fallSpan)
+	}
+	if *dbFloor >= *dbCeiling {
+		log.Fatalf("--db-floor (%g) must be below --db-ceiling (%g)", *dbFloor, *dbCeiling)
+	}
+	if *cellAspect < 1 || *cellAspect > 3 {
+		log.Fatalf("--cell-aspect must be between 1 and 3, got %g", *cellAspect)
+	}
+	if err := ValidateVisualizer(*modeName); err != nil {
+		log.Fatal(err)
+	}
+	sampleRate := *captureRate
+	// Capture one hop at a time so each buffer completes exactly one analysis
+	framesPerBuffer := *hopSize
 
-	LogInfo("Initializing PortAudio (rate=%d, buffer=%d, channels=%d)", sampleRate, framesPerBuffer, channels)
-	fmt.Fprintln(os.Stderr, "[DEBUG] About to initialize PortAudio")
-
-	// Initialize PortAudio early so device queries work (used by detectAudioSetup)
-	if err := portaudio.Initialize(); err != nil {
-		LogError("Failed to initialize PortAudio: %v", err)
-		log.Fatal("failed to initialize PortAudio:", err)
+	if *backendName == "" && *inputFile != "" {
+		*backendName = "file"
+	}
+	if *backendName == "" && *fifoPath != "" {
+		*backendName = "stdin"
+	}
+	if *backendName == "" && *signalKind != "" {
+		*backendName = "synthetic"
+	}
+	if *backendName == "" && *replayPath != "" {
+		*backendName = "replay"
+	}
+	if *backendName == "" {
+		*backendName = defaultCaptureBackend()
 	}
-	defer portaudio.Terminate()
+	*backendName = strings.ToLower(*backendName)
 
-	LogInfo("PortAudio initialized successfully")
+	// Only PortAudio capture and file playback talk to PortAudio
+	switch *backendName {
+	case "portaudio":
+		LogInfo("Initializing PortAudio (rate=%d, buffer=%d)", sampleRate, framesPerBuffer)
+		fmt.Fprintln(os.Stderr, "[DEBUG] About to initialize PortAudio")
 
-	if err := detectAudioSetup(); err != nil {
-		LogError("Audio setup detection failed: %v", err)
-		log.Fatal(err)
-	}
+		// Initialize PortAudio before the backend so device queries work (used by detectAudioSetup)
+		if err := portaudio.Initialize(); err != nil {
+			LogError("Failed to initialize PortAudio: %v", err)
+			log.Fatal("failed to initialize PortAudio:", err)
+		}
+		defer portaudio.Terminate()
 
-	LogInfo("Audio setup detected")
+		LogInfo("PortAudio initialized successfully")
+
+		if err := detectAudioSetup(); err != nil {
+			LogError("Audio setup detection failed: %v", err)
+			log.Fatal(err)
+		}
+
+		LogInfo("Audio setup detected")
+	case "file":
+		// Without PortAudio the file is visualized silently
+		if *muteFile {
+			break
+		}
+		if err := portaudio.Initialize(); err != nil {
+			LogError("Failed to initialize PortAudio: %v", err)
+		} else {
+			defer portaudio.Terminate()
+		}
+	}
 
-	LogInfo("Starting audio capture")
-	audioChan, stopAudio, audioErr := StartAudioCapture(sampleRate, framesPerBuffer)
+	sourcePath := *inputFile
+	switch *backendName {
+	case "stdin":
+		sourcePath = *fifoPath
+	case "replay":
+		sourcePath = *replayPath
+	}
+
+	backend, backendErr := NewCaptureBackend(*backendName, CaptureConfig{
+		SampleRate:      sampleRate,
+		FramesPerBuffer: framesPerBuffer,
+		Channels:        *channelsArg,
+		Device:          *deviceName,
+		App:             *captureApp,
+		Encoding:        SampleFormat(*pcmFormat),
+		Path:            sourcePath,
+		Mute:            *muteFile,
+		Signal:          *signalKind,
+		BPM:             *clickBPM,
+	})
+	if backendErr != nil {
+		LogError("Failed to create capture backend: %v", backendErr)
+		log.Fatal(backendErr)
+	}
+	format := backend.Format()
+
+	edges, edgesErr := ParseBandEdges(*bandEdges)
+	if edgesErr != nil {
+		LogError("Invalid band edges: %v", edgesErr)
+		log.Fatal(edgesErr)
+	}
+	bands, bandsErr := BuildBands(*bandLayout, *bandCount, edges, format.SampleRate)
+	if bandsErr != nil {
+		LogError("Invalid band layout: %v", bandsErr)
+		log.Fatal(bandsErr)
+	}
+
+	LogInfo("Starting audio capture (backend=%s, %s)", backend.Name(), format)
+	audioChan, stopAudio, audioErr := backend.Start()
 	if audioErr != nil {
 		LogError("Failed to start audio capture: %v", audioErr)
 		log.Fatal(audioErr)
 	}
 	defer stopAudio()
 
-	LogInfo("PortAudio stream started successfully")
+	LogInfo("Audio capture started successfully")
 
 	LogInfo("Creating audio processor")
-	processor, procErr := NewAudioProcessor(sampleRate, framesPerBuffer)
+	processor, procErr := NewAudioProcessor(format, AnalysisConfig{
+		FFTSize:  *fftSize,
+		HopSize:  *hopSize,
+		Window:   *windowName,
+		Analyzer: *analyzerArg,
+		Bands:    bands,
+		Chaos:    *chaosSpec,
+		AGC: AGCConfig{
+			Mode:        *agcMode,
+			Target:      *agcTarget,
+			Attack:      *agcAttack,
+			Release:     *agcRelease,
+			Sensitivity: *sensitivity,
+		},
+		IdleAfter:     *idleAfter,
+		IdleThreshold: *idleLevel,
+	})
 	if procErr != nil {
 		LogError("Failed to create audio processor: %v", procErr)
 		log.Fatal(procErr)
@@ -452,7 +460,27 @@ func runVisualizer() {
 
 	LogInfo("Audio processor created successfully")
 
-	frameChan := make(chan AudioFrame, 10)
+	// Replays carry their own metadata
+	if src, ok := backend.(MetadataSource); ok {
+		processor.SetMetadataSource(src)
+	}
+
+	var recorder *SessionRecorder
+	if *recordPath != "" {
+		var recErr error
+		recorder, recErr = NewSessionRecorder(*recordPath, format)
+		if recErr != nil {
+			LogError("Failed to start recording: %v", recErr)
+			log.Fatal(recErr)
+		}
+		defer func() {
+			if err := recorder.Close(); err != nil {
+				LogError("Failed to finish recording: %v", err)
+			}
+		}()
+	}
+
+	frameChan := make(chan AudioFrame, 10) // must stay well under frameBuffers
 	quitAudio := make(chan struct{})
 	var wg sync.WaitGroup
 
@@ -471,6 +499,16 @@ func runVisualizer() {
 
 		LogDebug("Audio goroutine running")
 
+		emit := func(msg AudioFrame) {
+			if recorder != nil {
+				recorder.WriteFrame(msg)
+			}
+			select {
+			case frameChan <- msg:
+			case <-quitAudio:
+			}
+		}
+
 		for {
 			select {
 			case buffer, ok := <-audioChan:
@@ -484,12 +522,10 @@ func runVisualizer() {
 							LogPanic(r, "ProcessBuffer")
 						}
 					}()
-					msg := processor.ProcessBuffer(buffer)
-					select {
-					case frameChan <- msg:
-					case <-quitAudio:
-						return
+					if recorder != nil {
+						recorder.WriteSamples(buffer)
 					}
+					processor.ProcessBuffer(buffer, emit)
 				}()
 			case <-quitAudio:
 				return
@@ -500,19 +536,61 @@ func runVisualizer() {
 	LogInfo("Initializing TUI")
 	fmt.Fprintln(os.Stderr, "[INFO] Creating Bubbletea TUI...")
 
-	// Check if we're in an interactive terminal
-	if !isTerminalInteractive() {
+	// Detect terminal capabilities and get appropriate options
+	terminalOptions := append(detectTerminalCapabilities(), tea.WithFPS(*fps))
+
+	// Audio arriving on stdin means keys have to come from the controlling terminal
+	if sc, ok := backend.(stdinConsumer); ok && sc.ReadsStdin() {
+		tty, err := os.Open("/dev/tty")
+		if err != nil {
+			LogError("No controlling terminal for keyboard input: %v", err)
+			log.Fatal("stdin backend needs a terminal: ", err)
+		}
+		defer tty.Close()
+		terminalOptions = append(terminalOptions, tea.WithInput(tty))
+	} else if !isTerminalInteractive() {
+		// Check if we're in an interactive terminal
 		LogError("Not running in an interactive terminal")
 		fmt.Fprintln(os.Stderr, "[ERROR] This program requires an interactive terminal")
 		log.Fatal("Not an interactive terminal")
 	}
 
-	// Detect terminal capabilities and get appropriate options
-	terminalOptions := detectTerminalCapabilities()
+	tuiModel := initialModel(frameChan)
+	if playback, ok := backend.(PlaybackControl); ok {
+		tuiModel.playback = playback
+	}
+	tuiModel.tempoSync = *tempoSync
+	tuiModel.showMeter = *showMeter
+	if tuner, ok := tuiModel.visualizers["tuner"].(*TunerRenderer); ok {
+		tuner.SetReference(*tunerA4)
+	}
+	if bars, ok := tuiModel.visualizers["bars"].(*BarsRenderer); ok {
+		bars.SetLayout(*barWidth, *barGap)
+		bars.SetGravity(*peakGravity)
+	}
+	if scope, ok := tuiModel.visualizers["scope"].(*OscilloscopeRenderer); ok {
+		scope.SetPersistence(*phosphor)
+	}
+	if waterfall, ok := tuiModel.visualizers["waterfall"].(*WaterfallRenderer); ok {
+		waterfall.SetRange(*dbFloor, *dbCeiling)
+		waterfall.SetSpan(*fallSpan)
+		waterfall.SetHorizontal(*fallDir == "horizontal")
+	}
+	if radial, ok := tuiModel.visualizers["radial"].(*RadialRenderer); ok {
+		radial.SetCellAspect(*cellAspect)
+	}
+	tuiModel.viewMode = *modeName
+	tuiModel.fps, tuiModel.idleFPS = *fps, *idleFPS
+	if apps, ok := backend.(AppSelector); ok {
+		tuiModel.apps = apps
+	}
+	if reporter, ok := backend.(captureStatusReporter); ok {
+		tuiModel.statusChan = reporter.Status()
+	}
 
 	// Create Bubbletea program with detected options
 	p := tea.NewProgram(
-		initialModel(frameChan),
+		tuiModel,
 		terminalOptions...,
 	)
 
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"math"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
@@ -28,8 +29,9 @@ func DefaultMetadata() AudioMetadata {
 	}
 }
 
-// RenderMetadata creates the top 30% metadata display section
-func RenderMetadata(metadata AudioMetadata, width int, height int) string {
+// RenderMetadata creates the top 30% metadata display section. bpm is the
+// estimated tempo (0 if unknown) with its 0-1 confidence.
+func RenderMetadata(metadata AudioMetadata, bpm, tempoConfidence float64, width int, height int) string {
 	var output strings.Builder
 
 	// Title bar with retro aesthetic
@@ -79,6 +81,7 @@ func RenderMetadata(metadata AudioMetadata, width int, height int) string {
 	statusStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#00FF00")).Render(fmt.Sprintf("[%s] %s", statusChar, getStatusText(metadata.IsPlaying)))
 
 	output.WriteString(statusStyle)
+	output.WriteString(renderTempo(bpm, tempoConfidence))
 	output.WriteString("\n\n")
 
 	// Separator line
@@ -91,6 +94,19 @@ func RenderMetadata(metadata AudioMetadata, width int, height int) string {
 	return output.String()
 }
 
+// renderTempo shows the BPM with a five step confidence meter
+func renderTempo(bpm, confidence float64) string {
+	tempoStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FFD700")).Bold(true)
+	if bpm <= 0 {
+		return tempoStyle.Faint(true).Render("   ♩ --- BPM")
+	}
+
+	steps := int(math.Round(confidence * 5))
+	meter := strings.Repeat("●", steps) + strings.Repeat("○", 5-steps)
+	return tempoStyle.Render(fmt.Sprintf("   ♩ %3.0f BPM ", bpm)) +
+		lipgloss.NewStyle().Foreground(lipgloss.Color("#888888")).Render(meter)
+}
+
 func getStatusText(isPlaying bool) string {
 	if isPlaying {
 		return "PLAYING"
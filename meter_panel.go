@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	meterPanelWidth = 34
+	meterFloor      = -60.0 // LUFS at the left end of the bars
+	meterTarget     = -14.0 // typical streaming target, marked on the bars
+	truePeakLimit   = -1.0  // dBTP, above this the peak turns red
+)
+
+// RenderLoudnessPanel draws the EBU R128 meter shown next to the metadata
+func RenderLoudnessPanel(reading LoudnessReading, width int, height int) string {
+	var output strings.Builder
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("#00FFFF")).
+		Background(lipgloss.Color("#1A0033")).
+		Width(width).
+		Align(lipgloss.Center)
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#00FFFF")).Bold(true)
+
+	output.WriteString(titleStyle.Render("LOUDNESS (EBU R128)"))
+	output.WriteString("\n\n")
+
+	barWidth := max(width-18, 4)
+	rows := []struct {
+		label string
+		value float64
+		bar   bool
+	}{
+		{"M ", reading.Momentary, true},
+		{"S ", reading.ShortTerm, true},
+		{"I ", reading.Integrated, false},
+	}
+	for _, row := range rows {
+		output.WriteString(labelStyle.Render(row.label))
+		output.WriteString(lipgloss.NewStyle().Foreground(loudnessColor(row.value)).Render(formatLevel(row.value) + " LUFS "))
+		if row.bar {
+			output.WriteString(renderLoudnessBar(row.value, barWidth))
+		}
+		output.WriteString("\n")
+	}
+
+	peakColor := lipgloss.Color("#3DFF4E")
+	if reading.TruePeak > truePeakLimit {
+		peakColor = lipgloss.Color("#FF3030")
+	}
+	output.WriteString(labelStyle.Render("TP"))
+	output.WriteString(lipgloss.NewStyle().Foreground(peakColor).Render(formatLevel(reading.TruePeak) + " dBTP"))
+	output.WriteString("\n")
+
+	return lipgloss.NewStyle().Width(width).MaxHeight(height).Render(output.String())
+}
+
+// renderLoudnessBar fills from meterFloor to 0 LUFS with a tick at meterTarget
+func renderLoudnessBar(value float64, width int) string {
+	filled := 0
+	if !math.IsInf(value, -1) {
+		filled = int(math.Round((value - meterFloor) / -meterFloor * float64(width)))
+		filled = max(0, min(width, filled))
+	}
+	target := int((meterTarget - meterFloor) / -meterFloor * float64(width))
+
+	var bar strings.Builder
+	for i := range width {
+		switch {
+		case i < filled:
+			bar.WriteString("█")
+		case i == target:
+			bar.WriteString("┆")
+		default:
+			bar.WriteString("░")
+		}
+	}
+	return lipgloss.NewStyle().Foreground(loudnessColor(value)).Render(bar.String())
+}
+
+// loudnessColor goes from cyan (quiet) over green (around the target) to red (hot)
+func loudnessColor(value float64) lipgloss.Color {
+	switch {
+	case value > -9:
+		return lipgloss.Color("#FF3030")
+	case value > meterTarget+1:
+		return lipgloss.Color("#FFD400")
+	case value > -23:
+		return lipgloss.Color("#3DFF4E")
+	default:
+		return lipgloss.Color("#00E5FF")
+	}
+}
+
+// formatLevel prints a dB value in a fixed width, "-inf" for silence
+func formatLevel(value float64) string {
+	if math.IsInf(value, -1) || value < -99 {
+		return "  -inf"
+	}
+	return fmt.Sprintf("%6.1f", value)
+}
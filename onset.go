@@ -0,0 +1,132 @@
+package main
+
+import (
+	"math"
+	"math/cmplx"
+	"time"
+)
+
+const (
+	onsetCompression = 100.0                   // log compression of magnitudes before the flux
+	onsetWindow      = 1500 * time.Millisecond // history the adaptive threshold looks at
+	onsetThreshold   = 2.0                     // standard deviations above the mean that count as an onset
+	onsetRefractory  = 100 * time.Millisecond  // minimum time between two beats
+	onsetMinFlux     = 1e-3                    // ignore onsets in near silence
+	onsetMinRatio    = 2.0                     // and ones that barely rise above the average flux
+)
+
+// binRange is the [lo, hi) FFT bins covered by one band
+type binRange struct {
+	lo, hi int
+}
+
+// onsetStats tracks an exponentially weighted mean and variance, the
+// adaptive part of the threshold
+type onsetStats struct {
+	mean, variance float64
+}
+
+func (s *onsetStats) add(x, alpha float64) {
+	diff := x - s.mean
+	s.mean += alpha * diff
+	s.variance = (1 - alpha) * (s.variance + alpha*diff*diff)
+}
+
+// novelty is how far x stands out above the running mean, in units of the
+// onset threshold (1 = just an onset)
+func (s *onsetStats) novelty(x float64) float64 {
+	std := math.Sqrt(s.variance)
+	if std < 1e-9 {
+		return 0
+	}
+	return math.Max(0, (x-s.mean)/(onsetThreshold*std))
+}
+
+// OnsetDetector finds note and drum onsets using spectral flux: the summed
+// rise of the log-compressed spectrum since the previous frame, compared
+// against an adaptive threshold over the last onsetWindow.
+type OnsetDetector struct {
+	hop        time.Duration
+	alpha      float64
+	ranges     []binRange
+	current    []float64 // compressed magnitude spectrum of this frame
+	previous   []float64 // and of the last one
+	global     onsetStats
+	bands      []onsetStats
+	bandFlux   []float64
+	sinceBeat  time.Duration
+	lastFlux   float64
+	primedHops int
+}
+
+func NewOnsetDetector(sampleRate, hopSize int, ranges []binRange, bins int) *OnsetDetector {
+	hop := time.Duration(hopSize) * time.Second / time.Duration(sampleRate)
+	return &OnsetDetector{
+		hop:       hop,
+		alpha:     float64(hop) / float64(onsetWindow),
+		ranges:    ranges,
+		current:   make([]float64, bins),
+		previous:  make([]float64, bins),
+		bands:     make([]onsetStats, len(ranges)),
+		bandFlux:  make([]float64, len(ranges)),
+		sinceBeat: onsetRefractory,
+	}
+}
+
+// Process takes the spectra of one analysis frame and returns whether it
+// holds a beat, the overall onset strength (0-1) and per-band onset
+// strengths written into bandOnsets
+func (od *OnsetDetector) Process(left, right []complex128, bandOnsets []float64) (bool, float64) {
+	for j := range od.current {
+		od.current[j] = math.Log1p(onsetCompression * (cmplx.Abs(left[j]) + cmplx.Abs(right[j])) / 2)
+	}
+
+	// Half-wave rectified difference, averaged per band so narrow bass bands
+	// weigh as much as the wide treble ones
+	var flux float64
+	for bi, r := range od.ranges {
+		od.bandFlux[bi] = 0
+		for j := r.lo; j < r.hi; j++ {
+			if rise := od.current[j] - od.previous[j]; rise > 0 {
+				od.bandFlux[bi] += rise
+			}
+		}
+		od.bandFlux[bi] /= float64(max(r.hi-r.lo, 1))
+		flux += od.bandFlux[bi]
+	}
+	flux /= float64(max(len(od.ranges), 1))
+	od.current, od.previous = od.previous, od.current
+
+	// Let the statistics settle before reporting anything
+	od.sinceBeat += od.hop
+	if time.Duration(od.primedHops)*od.hop < onsetWindow/2 {
+		od.primedHops++
+		od.global.add(flux, od.alpha)
+		for i := range od.bands {
+			od.bands[i].add(od.bandFlux[i], od.alpha)
+		}
+		return false, 0
+	}
+
+	strength := od.global.novelty(flux)
+	for i := range od.bands {
+		bandOnsets[i] = math.Min(1, od.bands[i].novelty(od.bandFlux[i]))
+		od.bands[i].add(od.bandFlux[i], od.alpha)
+	}
+
+	// A beat is a rising flux over the threshold, at most one per refractory period
+	beat := strength >= 1 && flux > od.lastFlux && flux > onsetMinFlux && flux > onsetMinRatio*od.global.mean &&
+		od.sinceBeat >= onsetRefractory
+	if beat {
+		od.sinceBeat = 0
+	}
+	od.lastFlux = flux
+	od.global.add(flux, od.alpha)
+
+	return beat, math.Min(1, strength/2)
+}
+
+// Flux is the spectral flux of the last frame, the onset envelope
+func (od *OnsetDetector) Flux() float64 {
+	return od.lastFlux
+}
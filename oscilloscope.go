@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	scopeDecay = 0.97 // per frame fall of the auto gain
+	scopeFloor = 1e-3 // gain stops rising below this level
+)
+
+// Newest to oldest trace colors per scheme and channel, older traces only
+// show up with persistence
+var (
+	scopeLeftColors  = []lipgloss.Color{"#3DFF4E", "#2BC43A", "#1E8C29", "#135A1A", "#0A330F"}
+	scopeRightColors = []lipgloss.Color{"#FFD400", "#C4A300", "#8C7400", "#5A4B00", "#332A00"}
+	scopeLeftRetro   = []lipgloss.Color{"#FF00FF", "#C400C4", "#8C008C", "#5A005A", "#330033"}
+	scopeRightRetro  = []lipgloss.Color{"#00FFFF", "#00C4C4", "#008C8C", "#005A5A", "#003333"}
+	scopeAxis        = lipgloss.Color("#3A3A3A")
+)
+
+// OscilloscopeRenderer draws the waveform of each channel in its own lane,
+// left on top, with optional phosphor persistence of the previous traces
+type OscilloscopeRenderer struct {
+	history     []Waveform // newest last, 1 + persistence entries
+	persistence int
+	level       float64 // auto gain, tracks the peak amplitude
+	leftColors  []lipgloss.Color
+	rightColors []lipgloss.Color
+	canvas      *BrailleCanvas
+	cache       *RenderCache
+}
+
+func NewOscilloscopeRenderer() *OscilloscopeRenderer {
+	return &OscilloscopeRenderer{
+		level:       scopeFloor,
+		leftColors:  scopeLeftColors,
+		rightColors: scopeRightColors,
+		canvas:      NewBrailleCanvas(0, 0),
+		cache:       NewRenderCache(),
+	}
+}
+
+// SetPersistence keeps this many older traces on screen, fading out
+func (osc *OscilloscopeRenderer) SetPersistence(frames int) {
+	osc.persistence = max(0, min(frames, len(scopeLeftColors)-1))
+}
+
+func (osc *OscilloscopeRenderer) SetColorScheme(scheme string) {
+	if scheme == "retro" {
+		osc.leftColors, osc.rightColors = scopeLeftRetro, scopeRightRetro
+	} else {
+		osc.leftColors, osc.rightColors = scopeLeftColors, scopeRightColors
+	}
+}
+
+// Update adds the frame's waveform and follows its peak
+func (osc *OscilloscopeRenderer) Update(frame AudioFrame) {
+	peak := 0.0
+	for i := range frame.Waveform.Left {
+		peak = math.Max(peak, math.Max(math.Abs(float64(frame.Waveform.Left[i])), math.Abs(float64(frame.Waveform.Right[i]))))
+	}
+	osc.level = math.Max(math.Max(peak, osc.level*scopeDecay), scopeFloor)
+
+	// The frame's samples are reused by the processor, so the history keeps
+	// copies, in the buffers of the trace that drops out
+	var trace Waveform
+	if drop := len(osc.history) - osc.persistence; drop > 0 {
+		trace = osc.history[0]
+		copy(osc.history, osc.history[drop:])
+		osc.history = osc.history[:osc.persistence]
+	}
+	trace.Left = append(trace.Left[:0], frame.Waveform.Left...)
+	trace.Right = append(trace.Right[:0], frame.Waveform.Right...)
+	trace.Span, trace.Triggered = frame.Waveform.Span, frame.Waveform.Triggered
+	osc.history = append(osc.history, trace)
+}
+
+// Reset empties the screen and the auto gain
+func (osc *OscilloscopeRenderer) Reset() {
+	osc.history = nil
+	osc.level = scopeFloor
+}
+
+// Render draws both lanes with a status line underneath
+func (osc *OscilloscopeRenderer) Render(width, height int) string {
+	if width < 4 || height < 3 {
+		return ""
+	}
+	osc.canvas.Resize(width, height-1)
+	dotsW, dotsH := osc.canvas.DotSize()
+
+	lane := dotsH / 2
+	leftMid, rightMid := lane/2, lane+lane/2
+	osc.canvas.Line(0, leftMid, dotsW-1, leftMid, scopeAxis)
+	osc.canvas.Line(0, rightMid, dotsW-1, rightMid, scopeAxis)
+
+	scale := float64(lane/2-1) * 0.9 / osc.level
+	for age, wave := range osc.history {
+		newness := len(osc.history) - 1 - age
+		osc.drawTrace(wave.Left, leftMid, scale, dotsW, osc.leftColors[newness])
+		osc.drawTrace(wave.Right, rightMid, scale, dotsW, osc.rightColors[newness])
+	}
+
+	return osc.canvas.String(osc.cache) + "\n" + osc.renderStatus(width)
+}
+
+// drawTrace connects the samples across the full width around the lane's
+// centre line
+func (osc *OscilloscopeRenderer) drawTrace(samples []float32, mid int, scale float64, dotsW int, color lipgloss.Color) {
+	if len(samples) < 2 {
+		return
+	}
+	prevX, prevY := -1, 0
+	for i, s := range samples {
+		x := i * (dotsW - 1) / (len(samples) - 1)
+		y := mid - int(math.Round(float64(s)*scale))
+		if prevX >= 0 {
+			osc.canvas.Line(prevX, prevY, x, y, color)
+		}
+		prevX, prevY = x, y
+	}
+}
+
+// renderStatus labels the lanes and shows the time span and trigger state
+func (osc *OscilloscopeRenderer) renderStatus(width int) string {
+	trigger := lipgloss.NewStyle().Foreground(lipgloss.Color("#666666")).Render("free run")
+	var span float64
+	if len(osc.history) > 0 {
+		newest := osc.history[len(osc.history)-1]
+		span = float64(newest.Span.Microseconds()) / 1000
+		if newest.Triggered {
+			trigger = lipgloss.NewStyle().Foreground(lipgloss.Color("#3DFF4E")).Render("triggered")
+		}
+	}
+	status := lipgloss.NewStyle().Foreground(osc.leftColors[0]).Bold(true).Render("L") + " top  " +
+		lipgloss.NewStyle().Foreground(osc.rightColors[0]).Bold(true).Render("R") + " bottom  " +
+		fmt.Sprintf("%.1f ms  ", span) + trigger
+	return lipgloss.NewStyle().MaxWidth(width).Render(status)
+}
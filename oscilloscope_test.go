@@ -0,0 +1,31 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+// The processor rewrites a frame's samples later, the persistence traces
+// must not change with them
+func TestOscilloscopeKeepsCopies(t *testing.T) {
+	osc := NewOscilloscopeRenderer()
+	osc.SetPersistence(2)
+
+	wave := Waveform{Left: make([]float32, 64), Right: make([]float32, 64)}
+	for i := range 5 {
+		for j := range wave.Left {
+			wave.Left[j], wave.Right[j] = float32(i), -float32(i)
+		}
+		osc.Update(AudioFrame{Waveform: wave})
+	}
+
+	if len(osc.history) != 3 {
+		t.Fatalf("kept %d traces, want 3", len(osc.history))
+	}
+	for age, trace := range osc.history {
+		want := float32(2 + age)
+		if !slices.Equal(trace.Left, slices.Repeat([]float32{want}, 64)) || trace.Right[0] != -want {
+			t.Errorf("trace %d reads %g/%g, want %g/%g", age, trace.Left[0], trace.Right[0], want, -want)
+		}
+	}
+}
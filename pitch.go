@@ -0,0 +1,144 @@
+package main
+
+import (
+	"math"
+	"math/cmplx"
+	"time"
+
+	"gonum.org/v1/gonum/dsp/fourier"
+)
+
+const (
+	pitchMinFreq   = 40.0                  // a bass low E is 41Hz
+	pitchMaxFreq   = 2000.0                // well above a violin's open E
+	pitchWindow    = 2048                  // YIN integration window in samples
+	yinThreshold   = 0.15                  // dips in the normalized difference below this are candidates
+	pitchMinRMS    = 0.003                 // about -50 dBFS, quieter than that isn't worth tuning
+	pitchMaxPeriod = 0.3                   // above this normalized difference there's no clear pitch
+	pitchInterval  = 50 * time.Millisecond // a tuner needle doesn't need every hop
+)
+
+// PitchDetector estimates the fundamental frequency of a monophonic signal
+// with YIN. The difference function is computed through an FFT
+// autocorrelation instead of the quadratic sum.
+type PitchDetector struct {
+	sampleRate float64
+	minLag     int
+	maxLag     int
+	window     int
+
+	fft      *fourier.FFT
+	frame    []float64 // zero-padded first window
+	full     []float64 // all the samples, zero-padded
+	spectrum []complex128
+	scratch  []complex128
+	corr     []float64
+	cmndf    []float64 // cumulative mean normalized difference, by lag
+}
+
+func NewPitchDetector(sampleRate int) *PitchDetector {
+	maxLag := int(math.Ceil(float64(sampleRate) / pitchMinFreq))
+	size := 1
+	for size < 2*pitchWindow+maxLag {
+		size *= 2
+	}
+	return &PitchDetector{
+		sampleRate: float64(sampleRate),
+		minLag:     max(int(float64(sampleRate)/pitchMaxFreq), 2),
+		maxLag:     maxLag,
+		window:     pitchWindow,
+		fft:        fourier.NewFFT(size),
+		frame:      make([]float64, size),
+		full:       make([]float64, size),
+		spectrum:   make([]complex128, size/2+1),
+		scratch:    make([]complex128, size/2+1),
+		corr:       make([]float64, size),
+		cmndf:      make([]float64, maxLag+2),
+	}
+}
+
+// History is how many samples Process needs
+func (pd *PitchDetector) History() int {
+	return pd.window + pd.maxLag + 1
+}
+
+// Process takes the last History() mono samples, oldest first, and returns
+// the fundamental in Hz and a 0-1 clarity. The frequency is 0 when there's
+// no clear pitch.
+func (pd *PitchDetector) Process(samples []float64) (float64, float64) {
+	var energy float64
+	for _, x := range samples {
+		energy += x * x
+	}
+	if math.Sqrt(energy/float64(len(samples))) < pitchMinRMS {
+		return 0, 0
+	}
+
+	// r(lag) = sum of x[j]*x[j+lag] over the window, as the cross-correlation
+	// of the first window with the whole buffer
+	clear(pd.frame)
+	clear(pd.full)
+	copy(pd.frame, samples[:pd.window])
+	copy(pd.full, samples)
+	pd.fft.Coefficients(pd.spectrum, pd.full)
+	pd.fft.Coefficients(pd.scratch, pd.frame)
+	for i := range pd.spectrum {
+		pd.spectrum[i] *= cmplx.Conj(pd.scratch[i])
+	}
+	pd.fft.Sequence(pd.corr, pd.spectrum)
+	norm := float64(len(pd.corr))
+
+	// d(lag) = e(0) + e(lag) - 2r(lag), with the energies as a running sum
+	var e0 float64
+	for _, x := range samples[:pd.window] {
+		e0 += x * x
+	}
+	eLag := e0
+	var running float64
+	pd.cmndf[0] = 1
+	for lag := 1; lag <= pd.maxLag+1; lag++ {
+		out, in := samples[lag-1], samples[lag-1+pd.window]
+		eLag += in*in - out*out
+		d := math.Max(e0+eLag-2*pd.corr[lag]/norm, 0)
+		running += d
+		if running > 0 {
+			pd.cmndf[lag] = d * float64(lag) / running
+		} else {
+			pd.cmndf[lag] = 1
+		}
+	}
+
+	// First dip under the threshold, followed down to its minimum. Without
+	// one the best dip still counts if it's clear enough.
+	best := -1
+	for lag := pd.minLag; lag <= pd.maxLag; lag++ {
+		if pd.cmndf[lag] < yinThreshold {
+			for lag+1 <= pd.maxLag && pd.cmndf[lag+1] < pd.cmndf[lag] {
+				lag++
+			}
+			best = lag
+			break
+		}
+	}
+	if best < 0 {
+		best = pd.minLag
+		for lag := pd.minLag; lag <= pd.maxLag; lag++ {
+			if pd.cmndf[lag] < pd.cmndf[best] {
+				best = lag
+			}
+		}
+		if pd.cmndf[best] > pitchMaxPeriod {
+			return 0, 0
+		}
+	}
+
+	// Parabolic interpolation for sub-sample lag
+	period := float64(best)
+	if best > 1 && best < pd.maxLag+1 {
+		a, b, c := pd.cmndf[best-1], pd.cmndf[best], pd.cmndf[best+1]
+		if denom := a - 2*b + c; denom > 0 {
+			period += 0.5 * (a - c) / denom
+		}
+	}
+	return pd.sampleRate / period, math.Max(0, 1-pd.cmndf[best])
+}
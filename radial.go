@@ -0,0 +1,162 @@
+package main
+
+import (
+	"math"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	defaultCellAspect = 2.0  // terminal cell height over width on most fonts
+	radialRingRatio   = 0.3  // ring radius at rest, share of the available radius
+	radialRingPulse   = 0.35 // how much bass widens the ring, share of its rest radius
+	radialSpokeWidth  = 0.5  // share of the angle between spokes a spoke fills
+	radialSpin        = 0.08 // radians per unit of noise time
+)
+
+// RadialRenderer draws the bands as spokes around a ring that pulses with the
+// bass. The bands go round twice, mirrored, so the figure stays symmetric,
+// and the whole thing turns slowly with the noise clock.
+type RadialRenderer struct {
+	noiseGen    *NoiseGenerator
+	palette     []lipgloss.Color // scheme colors, spread over the bands
+	colors      []lipgloss.Color
+	levels      []float64 // smoothed with bandPhysics
+	bandPhysics []BandPhysics
+	bass        float64
+	cellAspect  float64 // cell height over width, to keep circles round
+	canvas      *BrailleCanvas
+	cache       *RenderCache
+}
+
+func NewRadialRenderer(noiseGen *NoiseGenerator) *RadialRenderer {
+	rr := &RadialRenderer{
+		noiseGen:   noiseGen,
+		palette:    beamDefaultColors,
+		cellAspect: defaultCellAspect,
+		canvas:     NewBrailleCanvas(0, 0),
+		cache:      NewRenderCache(),
+	}
+	rr.resize(len(frequencyBands))
+	return rr
+}
+
+// resize adapts per-band state to a new band count
+func (rr *RadialRenderer) resize(n int) {
+	rr.levels = make([]float64, n)
+	rr.bandPhysics = make([]BandPhysics, n)
+	for i := range n {
+		rr.bandPhysics[i] = physicsForBand(i, n)
+	}
+	rr.colors = spreadPalette(rr.palette, n)
+}
+
+// SetCellAspect sets the height to width ratio of a terminal cell
+func (rr *RadialRenderer) SetCellAspect(aspect float64) {
+	rr.cellAspect = aspect
+}
+
+func (rr *RadialRenderer) SetColorScheme(scheme string) {
+	switch scheme {
+	case "retro":
+		rr.palette = beamRetroColors
+	default:
+		rr.palette = beamDefaultColors
+	}
+	rr.colors = spreadPalette(rr.palette, len(rr.colors))
+}
+
+// Update eases the spokes towards the frame's bands, and the ring towards
+// the level of the lowest quarter of them
+func (rr *RadialRenderer) Update(frame AudioFrame) {
+	if len(frame.Bands) != len(rr.levels) {
+		rr.resize(len(frame.Bands))
+	}
+	for i, energy := range frame.Bands {
+		physics := rr.bandPhysics[i]
+		rate := physics.Decay
+		if energy > rr.levels[i] {
+			rate = physics.Attack
+		}
+		rr.levels[i] += (math.Max(0, math.Min(1, energy)) - rr.levels[i]) * rate
+	}
+
+	lows := max(len(rr.levels)/4, 1)
+	bass := 0.0
+	for _, level := range rr.levels[:min(lows, len(rr.levels))] {
+		bass += level / float64(lows)
+	}
+	physics := lowFreqPhysics
+	rate := physics.Decay
+	if bass > rr.bass {
+		rate = physics.Attack
+	}
+	rr.bass += (bass - rr.bass) * rate
+}
+
+// Reset lets the spokes and the ring fall back to rest
+func (rr *RadialRenderer) Reset() {
+	rr.resize(len(rr.levels))
+	rr.bass = 0
+}
+
+func (rr *RadialRenderer) Render(width, height int) string {
+	rr.canvas.Resize(width, height)
+	n := len(rr.levels)
+	if n == 0 || width < 4 || height < 2 {
+		return rr.canvas.String(rr.cache)
+	}
+	dotsW, dotsH := rr.canvas.DotSize()
+
+	// Work in units of a dot's width. A dot is a quarter cell high and half
+	// a cell wide, so it's cellAspect/2 times as high as it is wide.
+	dotAspect := rr.cellAspect / 2
+	cx, cy := float64(dotsW-1)/2, float64(dotsH-1)/2
+	radius := math.Min(cx, cy*dotAspect) - 1
+	if radius < 4 {
+		return rr.canvas.String(rr.cache)
+	}
+	point := func(angle, r float64) (int, int) {
+		// 0 at the top, clockwise
+		return int(math.Round(cx + r*math.Sin(angle))), int(math.Round(cy - r*math.Cos(angle)/dotAspect))
+	}
+
+	ring := radius * radialRingRatio * (1 + radialRingPulse*rr.bass)
+	spin := rr.noiseGen.time * radialSpin
+	spokes := 2 * n
+	spacing := 2 * math.Pi / float64(spokes)
+
+	for k := range spokes {
+		// 0..n-1 clockwise from the top, then back down the other side
+		band := k
+		if k >= n {
+			band = spokes - 1 - k
+		}
+		length := rr.levels[band] * (radius - ring)
+		if length < 0.5 {
+			continue
+		}
+		color := rr.cache.ApplyGradient(rr.colors[band], 0.45+0.55*rr.levels[band])
+
+		// A fan of rays wide enough to fill the spoke at its outer end
+		centre := spin + (float64(k)+0.5)*spacing
+		fan := spacing * radialSpokeWidth
+		rays := max(int(math.Ceil(fan*(ring+length))), 1)
+		for ray := range rays {
+			angle := centre - fan/2 + fan*(float64(ray)+0.5)/float64(rays)
+			x0, y0 := point(angle, ring+1)
+			x1, y1 := point(angle, ring+length)
+			rr.canvas.Line(x0, y0, x1, y1, color)
+		}
+	}
+
+	// The ring on top, one dot per dot of circumference, brighter on bass
+	ringColor := rr.cache.ApplyGradient(rr.colors[0], 0.4+0.6*rr.bass)
+	steps := max(int(2*math.Pi*ring), 16)
+	for s := range steps {
+		x, y := point(2*math.Pi*float64(s)/float64(steps), ring)
+		rr.canvas.Set(x, y, ringColor)
+	}
+
+	return rr.canvas.String(rr.cache)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bufio"
+	"encoding/gob"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Bump sessionVersion whenever AudioFrame changes shape. Version 1 frames had
+// a fixed [9]float64 of bands and none of the later analysis.
+const (
+	sessionMagic   = "termulizer-session"
+	sessionVersion = 2
+)
+
+// sessionHeader starts every recording
+type sessionHeader struct {
+	Magic   string
+	Version int
+	Format  CaptureFormat
+	Started time.Time
+}
+
+// sessionRecord is either a raw capture buffer or the AudioFrame the
+// pipeline produced, stamped with its offset from the start of the session
+type sessionRecord struct {
+	Offset  time.Duration
+	Samples []float32
+	Frame   *AudioFrame
+}
+
+// SessionRecorder writes the sample and frame streams of a run to a gob file
+// so it can be replayed later with --replay. Safe for concurrent use.
+type SessionRecorder struct {
+	mu      sync.Mutex
+	file    *os.File
+	buf     *bufio.Writer
+	enc     *gob.Encoder
+	started time.Time
+	failed  bool
+}
+
+func NewSessionRecorder(path string, format CaptureFormat) (*SessionRecorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create recording: %w", err)
+	}
+
+	buf := bufio.NewWriter(f)
+	rec := &SessionRecorder{
+		file:    f,
+		buf:     buf,
+		enc:     gob.NewEncoder(buf),
+		started: time.Now(),
+	}
+
+	header := sessionHeader{
+		Magic:   sessionMagic,
+		Version: sessionVersion,
+		Format:  format,
+		Started: rec.started,
+	}
+	if err := rec.enc.Encode(header); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to write recording header: %w", err)
+	}
+
+	LogInfo("Recording session to %s", path)
+	return rec, nil
+}
+
+// WriteSamples records one capture buffer
+func (sr *SessionRecorder) WriteSamples(samples []float32) {
+	sr.write(sessionRecord{Samples: samples})
+}
+
+// WriteFrame records one analyzed frame
+func (sr *SessionRecorder) WriteFrame(frame AudioFrame) {
+	sr.write(sessionRecord{Frame: &frame})
+}
+
+func (sr *SessionRecorder) write(rec sessionRecord) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	if sr.failed {
+		return
+	}
+	rec.Offset = time.Since(sr.started)
+	if err := sr.enc.Encode(rec); err != nil {
+		// Keep visualizing, just stop recording
+		LogError("Recording failed, stopping: %v", err)
+		sr.failed = true
+	}
+}
+
+func (sr *SessionRecorder) Close() error {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	if err := sr.buf.Flush(); err != nil {
+		sr.file.Close()
+		return err
+	}
+	return sr.file.Close()
+}
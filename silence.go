@@ -0,0 +1,37 @@
+package main
+
+import (
+	"math"
+	"time"
+)
+
+// SilenceDetector reports the input as idle once its level stayed below a
+// threshold for a while, and as active again on the first louder frame
+type SilenceDetector struct {
+	threshold float64 // linear RMS
+	after     time.Duration
+	hop       time.Duration
+	quiet     time.Duration
+}
+
+// NewSilenceDetector takes the threshold in dBFS. A zero after disables it.
+func NewSilenceDetector(thresholdDB float64, after time.Duration, sampleRate, hopSize int) *SilenceDetector {
+	return &SilenceDetector{
+		threshold: math.Pow(10, thresholdDB/20),
+		after:     after,
+		hop:       time.Duration(hopSize) * time.Second / time.Duration(sampleRate),
+	}
+}
+
+// Update takes the RMS of one hop and returns whether the input is idle
+func (sd *SilenceDetector) Update(rms float64) bool {
+	if sd.after <= 0 {
+		return false
+	}
+	if rms >= sd.threshold {
+		sd.quiet = 0
+		return false
+	}
+	sd.quiet += sd.hop
+	return sd.quiet >= sd.after
+}
@@ -0,0 +1,44 @@
+package main
+
+import "math"
+
+const (
+	spectrumPoints  = 256    // log spaced frequencies handed to the waterfall
+	spectrumMinFreq = 20.0   // Hz
+	spectrumMaxFreq = 20000  // Hz, points above Nyquist stay silent
+	spectrumSilence = -120.0 // dBFS reported for silent points
+)
+
+// logSpectrum resamples the magnitudes left by spectralFeatures onto
+// spectrumPoints log spaced frequencies in dBFS, appended to spectrum. A point
+// spanning several FFT bins takes the loudest, one between bins interpolates.
+func (ap *AudioProcessor) logSpectrum(spectrum []float32) []float32 {
+	binWidth := float64(ap.sampleRate) / float64(ap.fftSize)
+	last := len(ap.magnitudes) - 1
+	ratio := math.Pow(spectrumMaxFreq/spectrumMinFreq, 1/float64(spectrumPoints))
+
+	lo := spectrumMinFreq / binWidth
+	for range spectrumPoints {
+		hi := lo * ratio
+		var mag float64
+		if lo >= float64(last) {
+			spectrum = append(spectrum, spectrumSilence)
+			lo = hi
+			continue
+		}
+		if first, end := int(math.Ceil(lo)), min(int(math.Ceil(hi)), last+1); first < end {
+			for j := max(first, 1); j < end; j++ {
+				mag = math.Max(mag, ap.magnitudes[j])
+			}
+		} else {
+			// Bin 0 is DC, spectralFeatures leaves it out
+			pos := math.Max(math.Min((lo+hi)/2, float64(last)), 1)
+			j := int(pos)
+			frac := pos - float64(j)
+			mag = ap.magnitudes[j]*(1-frac) + ap.magnitudes[min(j+1, last)]*frac
+		}
+		spectrum = append(spectrum, float32(math.Max(20*math.Log10(mag*ap.spectrumGain+1e-12), spectrumSilence)))
+		lo = hi
+	}
+	return spectrum
+}
@@ -0,0 +1,21 @@
+package main
+
+import "testing"
+
+// With a small FFT the lowest points fall below bin 1, none of them may pick
+// up DC
+func TestLogSpectrumSkipsDC(t *testing.T) {
+	ap := newTestProcessor(t, AnalysisConfig{FFTSize: 1024})
+	clear(ap.magnitudes)
+	ap.magnitudes[0] = 1e6
+
+	spectrum := ap.logSpectrum(nil)
+	if len(spectrum) != spectrumPoints {
+		t.Fatalf("got %d points, want %d", len(spectrum), spectrumPoints)
+	}
+	for i, db := range spectrum {
+		if db != spectrumSilence {
+			t.Errorf("point %d reads %.1f dBFS from DC", i, db)
+		}
+	}
+}
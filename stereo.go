@@ -0,0 +1,68 @@
+package main
+
+import "math"
+
+const (
+	stereoMaxPoints = 512   // L/R pairs per frame handed to the vectorscope
+	stereoSilence   = 1e-10 // mean square below which the image isn't measured
+	maxMidSide      = 10.0  // cap for fully out of phase material
+)
+
+// StereoImage describes the stereo field of one analysis frame
+type StereoImage struct {
+	BandsLeft   []float64 // per-band levels of each channel, same scale as AudioFrame.Bands
+	BandsRight  []float64
+	Correlation float64      // phase correlation, 1 mono, 0 unrelated, -1 out of phase
+	Balance     float64      // -1 hard left to 1 hard right
+	MidSide     float64      // side/mid RMS ratio, 0 for mono
+	Points      [][2]float32 // recent L/R sample pairs, oldest first
+}
+
+// stereoImage measures the stereo field over the unwindowed ring and picks
+// the samples of the last hop for the vectorscope, its slices live in buf
+func (ap *AudioProcessor) stereoImage(buf *frameBuffer) StereoImage {
+	buf.bandsLeft = append(buf.bandsLeft[:0], ap.bandsLeft...)
+	buf.bandsRight = append(buf.bandsRight[:0], ap.bandsRight...)
+	image := StereoImage{
+		BandsLeft:  buf.bandsLeft,
+		BandsRight: buf.bandsRight,
+	}
+
+	var ll, rr, lr float64
+	for i := range ap.ringLeft {
+		l, r := ap.ringLeft[i], ap.ringRight[i]
+		ll += l * l
+		rr += r * r
+		lr += l * r
+	}
+	n := float64(len(ap.ringLeft))
+	if (ll+rr)/n > stereoSilence {
+		if ll > 0 && rr > 0 {
+			image.Correlation = lr / math.Sqrt(ll*rr)
+		}
+		left, right := math.Sqrt(ll), math.Sqrt(rr)
+		image.Balance = (right - left) / (right + left)
+
+		// M = (L+R)/2 and S = (L-R)/2, expanded so it needs no second pass
+		mid := ll + rr + 2*lr
+		side := ll + rr - 2*lr
+		switch {
+		case mid > 0:
+			image.MidSide = math.Min(math.Sqrt(math.Max(side, 0)/mid), maxMidSide)
+		case side > 0:
+			image.MidSide = maxMidSide
+		}
+	}
+
+	// The last hop, decimated so the vectorscope gets a bounded point count
+	ringSize := len(ap.ringLeft)
+	count := min(ap.hopSize, ringSize)
+	step := max(1, count/stereoMaxPoints)
+	points := buf.points[:0]
+	for i := count % step; i < count; i += step {
+		pos := (ap.ringPos - count + i + ringSize) % ringSize
+		points = append(points, [2]float32{float32(ap.ringLeft[pos]), float32(ap.ringRight[pos])})
+	}
+	buf.points, image.Points = points, points
+	return image
+}
@@ -10,15 +10,20 @@ import (
 
 // StrandRenderer handles vertical sine wave visualization
 type StrandRenderer struct {
-	colors           [9]lipgloss.Color
+	palette          []lipgloss.Color // scheme colors, spread over the bands
+	colors           []lipgloss.Color
 	noiseGen         *NoiseGenerator
-	smoothedEnergies [9]float64
-	previousEnergies [9]float64
-	bandPhysics      [9]BandPhysics
+	smoothedEnergies []float64
+	previousEnergies []float64
+	bandPhysics      []BandPhysics
 	chaosSmooth      float64
+
+	// Copies of the latest frame's bands and chaos, drawn on the next Render
+	bands      []float64
+	chaosLevel float64
 }
 
-var defaultColors = [9]lipgloss.Color{
+var defaultColors = []lipgloss.Color{
 	lipgloss.Color("#5A0000"), // Dark red
 	lipgloss.Color("#E10600"), // Red
 	lipgloss.Color("#FF7A00"), // Orange
@@ -30,7 +35,7 @@ var defaultColors = [9]lipgloss.Color{
 	lipgloss.Color("#FF00C8"), // Magenta
 }
 
-var retroColors = [9]lipgloss.Color{
+var retroColors = []lipgloss.Color{
 	lipgloss.Color("#FF0080"),
 	lipgloss.Color("#FF0099"),
 	lipgloss.Color("#FF00CC"),
@@ -43,37 +48,58 @@ var retroColors = [9]lipgloss.Color{
 }
 
 func NewStrandRenderer(noiseGen *NoiseGenerator) *StrandRenderer {
-	return &StrandRenderer{
-		colors:           defaultColors,
-		noiseGen:         noiseGen,
-		smoothedEnergies: [9]float64{},
-		previousEnergies: [9]float64{},
-		bandPhysics: [9]BandPhysics{
-			lowFreqPhysics,
-			lowFreqPhysics,
-			lowFreqPhysics,
-			midFreqPhysics,
-			midFreqPhysics,
-			midFreqPhysics,
-			highFreqPhysics,
-			highFreqPhysics,
-			highFreqPhysics,
-		},
+	sr := &StrandRenderer{
+		palette:     defaultColors,
+		noiseGen:    noiseGen,
 		chaosSmooth: 0.0,
 	}
+	sr.resize(len(frequencyBands))
+	return sr
+}
+
+// resize adapts per-band state to a new band count
+func (sr *StrandRenderer) resize(n int) {
+	sr.smoothedEnergies = make([]float64, n)
+	sr.previousEnergies = make([]float64, n)
+	sr.bandPhysics = make([]BandPhysics, n)
+	for i := range n {
+		sr.bandPhysics[i] = physicsForBand(i, n)
+	}
+	sr.colors = spreadPalette(sr.palette, n)
 }
 
 func (sr *StrandRenderer) SetColorScheme(scheme string) {
 	switch scheme {
 	case "retro":
-		sr.colors = retroColors
+		sr.palette = retroColors
 	default:
-		sr.colors = defaultColors
+		sr.palette = defaultColors
 	}
+	sr.colors = spreadPalette(sr.palette, len(sr.colors))
+}
+
+// Update keeps the frame for the next Render
+func (sr *StrandRenderer) Update(frame AudioFrame) {
+	sr.bands, sr.chaosLevel = append(sr.bands[:0], frame.Bands...), frame.ChaosLevel
 }
 
-// RenderVerticalWaves creates 9 vertical sine wave strands
-func (sr *StrandRenderer) RenderVerticalWaves(bands [9]float64, chaosLevel float64, width int, height int) string {
+func (sr *StrandRenderer) Render(width, height int) string {
+	return sr.RenderVerticalWaves(sr.bands, sr.chaosLevel, width, height)
+}
+
+// Reset drops the smoothing
+func (sr *StrandRenderer) Reset() {
+	sr.resize(len(sr.smoothedEnergies))
+	sr.chaosSmooth = 0
+	sr.bands, sr.chaosLevel = nil, 0
+}
+
+// RenderVerticalWaves creates one vertical sine wave strand per band
+func (sr *StrandRenderer) RenderVerticalWaves(bands []float64, chaosLevel float64, width int, height int) string {
+	if len(bands) != len(sr.smoothedEnergies) {
+		sr.resize(len(bands))
+	}
+
 	// Smooth the values to reduce jitter (exponential smoothing)
 	smoothFactor := 0.3
 	for i := range bands {
@@ -82,15 +108,13 @@ func (sr *StrandRenderer) RenderVerticalWaves(bands [9]float64, chaosLevel float
 	}
 	sr.chaosSmooth = sr.chaosSmooth*(1-smoothFactor) + chaosLevel*smoothFactor
 
-	LogDebug("Rendering: bands=[%.3f,%.3f,%.3f,%.3f,%.3f,%.3f,%.3f,%.3f,%.3f]",
-		sr.smoothedEnergies[0], sr.smoothedEnergies[1], sr.smoothedEnergies[2], sr.smoothedEnergies[3],
-		sr.smoothedEnergies[4], sr.smoothedEnergies[5], sr.smoothedEnergies[6], sr.smoothedEnergies[7], sr.smoothedEnergies[8])
+	LogDebug("Rendering: bands=%.3f", sr.smoothedEnergies)
 
 	// Calculate strand spacing (divide width by number of strands + padding)
-	numStrands := 9
+	numStrands := len(bands)
 	padding := 2
 	usableWidth := width - (padding * 2)
-	strandSpacing := usableWidth / (numStrands + 1)
+	strandSpacing := float64(usableWidth) / float64(numStrands+1)
 
 	// Create a 2D grid for rendering
 	grid := make([][]rune, height)
@@ -108,7 +132,7 @@ func (sr *StrandRenderer) RenderVerticalWaves(bands [9]float64, chaosLevel float
 
 	// Render each strand with the same test energy
 	for strandIdx := range numStrands {
-		baseX := padding + (strandIdx+1)*strandSpacing
+		baseX := padding + int(float64(strandIdx+1)*strandSpacing)
 		energy := sr.smoothedEnergies[strandIdx] // All should be the same now
 		color := sr.colors[strandIdx]
 
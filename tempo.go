@@ -0,0 +1,165 @@
+package main
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	tempoHistory     = 8 * time.Second        // onset envelope the autocorrelation looks at
+	tempoUpdateEvery = 500 * time.Millisecond // how often the estimate is refreshed
+	tempoMinBPM      = 60.0
+	tempoMaxBPM      = 200.0
+	tempoPriorBPM    = 120.0 // centre of the log-gaussian prior resolving double/half tempo
+	tempoPriorWidth  = 1.0   // in octaves
+	tempoTolerance   = 0.04  // relative change that still counts as the same tempo
+	tempoSwitchAfter = 3     // consistent updates needed before jumping to a new tempo
+	tempoMinConf     = 0.15  // below this the BPM isn't worth showing
+)
+
+// TempoTracker estimates the tempo by autocorrelating the onset envelope
+// (spectral flux) over the last few seconds. The estimate only jumps after a
+// new tempo was seen a few times in a row, so it doesn't flicker between
+// candidates.
+type TempoTracker struct {
+	frameRate float64 // envelope samples (analysis frames) per second
+	envelope  []float64
+	pos       int
+	filled    int
+	unrolled  []float64 // scratch, envelope oldest first without its mean
+	acf       []float64 // scratch, indexed by lag
+	minLag    int
+	maxLag    int
+	every     int
+	sinceRun  int
+
+	bpm        float64
+	confidence float64
+	candidate  float64
+	seen       int
+}
+
+func NewTempoTracker(sampleRate, hopSize int) *TempoTracker {
+	frameRate := float64(sampleRate) / float64(hopSize)
+	size := int(tempoHistory.Seconds() * frameRate)
+	maxLag := int(math.Ceil(60 / tempoMinBPM * frameRate))
+
+	return &TempoTracker{
+		frameRate: frameRate,
+		envelope:  make([]float64, size),
+		unrolled:  make([]float64, size),
+		acf:       make([]float64, maxLag+2),
+		minLag:    max(int(60/tempoMaxBPM*frameRate), 2),
+		maxLag:    min(maxLag, size/2),
+		every:     max(int(tempoUpdateEvery.Seconds()*frameRate), 1),
+	}
+}
+
+// Add feeds one frame's onset flux and returns the current BPM and a 0-1
+// confidence. BPM is 0 until there's something to report.
+func (tt *TempoTracker) Add(flux float64) (float64, float64) {
+	tt.envelope[tt.pos] = flux
+	tt.pos = (tt.pos + 1) % len(tt.envelope)
+	tt.filled = min(tt.filled+1, len(tt.envelope))
+
+	tt.sinceRun++
+	// Wait for a few beats worth of envelope at the slowest tempo
+	if tt.sinceRun >= tt.every && tt.filled > 4*tt.maxLag {
+		tt.sinceRun = 0
+		tt.estimate()
+	}
+
+	if tt.confidence < tempoMinConf {
+		return 0, tt.confidence
+	}
+	return tt.bpm, tt.confidence
+}
+
+func (tt *TempoTracker) estimate() {
+	n := tt.filled
+	start := (tt.pos - n + len(tt.envelope)) % len(tt.envelope)
+
+	var mean float64
+	for i := range n {
+		tt.unrolled[i] = tt.envelope[(start+i)%len(tt.envelope)]
+		mean += tt.unrolled[i]
+	}
+	mean /= float64(n)
+	for i := range n {
+		tt.unrolled[i] -= mean
+	}
+	env := tt.unrolled[:n]
+
+	var energy float64
+	for _, v := range env {
+		energy += v * v
+	}
+	if energy < 1e-12 {
+		tt.confidence = 0
+		return
+	}
+
+	top := min(tt.maxLag+1, n-1)
+	for lag := tt.minLag - 1; lag <= top; lag++ {
+		var sum float64
+		for i := lag; i < n; i++ {
+			sum += env[i] * env[i-lag]
+		}
+		// Unbiased and normalized so it reads like a correlation coefficient
+		tt.acf[lag] = sum / energy * float64(n) / float64(n-lag)
+	}
+
+	bestLag, bestScore, bestPeak := 0, 0.0, 0.0
+	for lag := tt.minLag; lag <= min(tt.maxLag, top-1); lag++ {
+		// A beat period between two lags splits its peak over both, count
+		// the stronger neighbour in so it isn't beaten by a whole-lag multiple
+		peak := tt.acf[lag] + math.Max(0, math.Max(tt.acf[lag-1], tt.acf[lag+1]))
+		bpm := 60 * tt.frameRate / float64(lag)
+		octaves := math.Log2(bpm / tempoPriorBPM)
+		score := peak * math.Exp(-0.5*octaves*octaves/(tempoPriorWidth*tempoPriorWidth))
+		if score > bestScore {
+			bestLag, bestScore, bestPeak = lag, score, peak
+		}
+	}
+	if bestLag == 0 {
+		tt.confidence = 0
+		return
+	}
+
+	// Refine around the stronger of the two lags sharing the peak
+	if tt.acf[bestLag-1] > tt.acf[bestLag] {
+		bestLag--
+	} else if tt.acf[bestLag+1] > tt.acf[bestLag] {
+		bestLag++
+	}
+
+	// Parabolic interpolation between lags for sub-frame resolution
+	lag := float64(bestLag)
+	if bestLag >= tt.minLag && bestLag < top {
+		a, b, c := tt.acf[bestLag-1], tt.acf[bestLag], tt.acf[bestLag+1]
+		if denom := a - 2*b + c; denom < 0 {
+			lag += 0.5 * (a - c) / denom
+		}
+	}
+	estimate := 60 * tt.frameRate / lag
+	conf := math.Max(0, math.Min(1, bestPeak))
+
+	switch {
+	case tt.bpm == 0 || math.Abs(estimate-tt.bpm)/tt.bpm < tempoTolerance:
+		// Same tempo, refine it
+		if tt.bpm == 0 {
+			tt.bpm = estimate
+		} else {
+			tt.bpm = tt.bpm*0.7 + estimate*0.3
+		}
+		tt.seen = 0
+	case tt.candidate != 0 && math.Abs(estimate-tt.candidate)/tt.candidate < tempoTolerance:
+		tt.seen++
+		if tt.seen >= tempoSwitchAfter {
+			tt.bpm, tt.seen = estimate, 0
+		}
+	default:
+		tt.candidate, tt.seen = estimate, 1
+	}
+	tt.confidence = tt.confidence*0.6 + conf*0.4
+}
@@ -2,6 +2,8 @@ package main
 
 import (
 	"fmt"
+	"math"
+	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -11,19 +13,35 @@ import (
 type model struct {
 	width        int
 	height       int
-	bands        [9]float64
-	chaosLevel   float64
 	frameChan    <-chan AudioFrame
 	noiseGen     *NoiseGenerator
-	beamRenderer *BeamRenderer
+	visualizers  map[string]Visualizer // by mode name, see visualizerModes
+	idleRenderer *IdleRenderer
+	idle         bool    // the pipeline reports sustained silence
+	idleMix      float64 // 0 audio view, 1 idle animation, eased in between
+	fps          int
+	idleFPS      int
+	viewMode     string // the visualizer on screen
 	metadata     AudioMetadata
+	bpm          float64
+	tempoConf    float64
+	tempoSync    bool // scale the animation speed with the song's tempo
+	loudness     LoudnessReading
+	gain         float64
+	showMeter    bool
+	animSpeed    float64 // smoothed animation speed multiplier
 	colorScheme  string
+	playback     PlaybackControl      // nil for live capture
+	apps         AppSelector          // nil if the backend can't capture per application
+	statusChan   <-chan CaptureStatus // nil if the backend can't recover by itself
+	status       CaptureStatus
 	ready        bool
 }
 
 type (
-	tickMsg  time.Time
-	audioMsg AudioFrame
+	tickMsg   time.Time
+	audioMsg  AudioFrame
+	statusMsg CaptureStatus
 )
 
 func initialModel(frameChan <-chan AudioFrame) model {
@@ -34,8 +52,13 @@ func initialModel(frameChan <-chan AudioFrame) model {
 	return model{
 		frameChan:    frameChan,
 		noiseGen:     noiseGen,
-		beamRenderer: NewBeamRenderer(noiseGen),
+		visualizers:  NewVisualizers(noiseGen),
+		idleRenderer: NewIdleRenderer(noiseGen),
+		fps:          60,
+		idleFPS:      15,
+		viewMode:     visualizerModes[0].name,
 		metadata:     DefaultMetadata(),
+		animSpeed:    1.0,
 		colorScheme:  "original",
 		ready:        false,
 	}
@@ -44,13 +67,14 @@ func initialModel(frameChan <-chan AudioFrame) model {
 func (m model) Init() tea.Cmd {
 	LogInfo("TUI Init() called")
 	return tea.Batch(
-		tickCmd(),
+		tickCmd(m.fps),
 		waitForAudio(m.frameChan),
+		waitForStatus(m.statusChan),
 	)
 }
 
-func tickCmd() tea.Cmd {
-	return tea.Tick(time.Second/60, func(t time.Time) tea.Msg {
+func tickCmd(fps int) tea.Cmd {
+	return tea.Tick(time.Second/time.Duration(fps), func(t time.Time) tea.Msg {
 		return tickMsg(t)
 	})
 }
@@ -66,6 +90,69 @@ func waitForAudio(ch <-chan AudioFrame) tea.Cmd {
 	}
 }
 
+// nextCaptureApp cycles all audio -> the playing MPRIS player -> each
+// application with a stream -> all audio
+func nextCaptureApp(current string, apps []string) string {
+	order := append([]string{"", appFollowPlayer}, apps...)
+	for i, app := range order {
+		if strings.EqualFold(app, current) {
+			return order[(i+1)%len(order)]
+		}
+	}
+	return ""
+}
+
+func waitForStatus(ch <-chan CaptureStatus) tea.Cmd {
+	if ch == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		status, ok := <-ch
+		if !ok {
+			return nil
+		}
+		return statusMsg(status)
+	}
+}
+
+// How long the idle animation takes to fade in, and out again when sound resumes
+const (
+	idleFadeIn  = 2 * time.Second
+	idleFadeOut = 400 * time.Millisecond
+)
+
+// frameRate drops to the idle rate once the idle animation has fully faded in
+func (m *model) frameRate() int {
+	if m.idle && m.idleMix >= 1 {
+		return m.idleFPS
+	}
+	return m.fps
+}
+
+// updateIdleMix eases towards the idle animation or back, dt in seconds
+func (m *model) updateIdleMix(dt float64) {
+	if m.idle {
+		m.idleMix = math.Min(1, m.idleMix+dt/idleFadeIn.Seconds())
+	} else {
+		m.idleMix = math.Max(0, m.idleMix-dt/idleFadeOut.Seconds())
+	}
+}
+
+// Tempo the animation speed is tuned for, --tempo-sync scales relative to it
+const referenceBPM = 120.0
+
+// animationDelta is the noise time step for one tick of dt seconds. With
+// --tempo-sync the speed follows the song's tempo, easing there so tempo
+// changes don't jump.
+func (m *model) animationDelta(dt float64) float64 {
+	target := 1.0
+	if m.tempoSync && m.bpm > 0 {
+		target = math.Max(0.5, math.Min(2.0, m.bpm/referenceBPM))
+	}
+	m.animSpeed += (target - m.animSpeed) * math.Min(1, 1.2*dt)
+	return m.animSpeed * dt
+}
+
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
@@ -80,8 +167,36 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			} else {
 				m.colorScheme = "original"
 			}
-			m.beamRenderer.SetColorScheme(m.colorScheme)
+			for _, v := range m.visualizers {
+				v.SetColorScheme(m.colorScheme)
+			}
+			m.idleRenderer.SetColorScheme(m.colorScheme)
 			LogDebug("Color scheme changed to: %s", m.colorScheme)
+		case "tab":
+			m.viewMode = nextVisualizer(m.viewMode, 1)
+		case "shift+tab":
+			m.viewMode = nextVisualizer(m.viewMode, -1)
+		case "r":
+			m.visualizers[m.viewMode].Reset()
+		case "m":
+			m.showMeter = !m.showMeter
+		case "p":
+			if m.playback != nil {
+				LogDebug("Playback paused: %v", m.playback.TogglePause())
+			}
+		case "a":
+			if m.apps != nil {
+				next := nextCaptureApp(m.apps.CaptureApp(), m.apps.CaptureApps())
+				m.apps.SetCaptureApp(next)
+			}
+		case "left", "right":
+			if m.playback != nil {
+				offset := 5 * time.Second
+				if msg.String() == "left" {
+					offset = -offset
+				}
+				m.playback.Seek(offset)
+			}
 		}
 
 	case tea.WindowSizeMsg:
@@ -91,18 +206,36 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		LogInfo("Window resized: %dx%d", m.width, m.height)
 
 	case tickMsg:
-		// update noise animation (60 FPS)
-		m.noiseGen.Update(1.0 / 60.0)
-		return m, tickCmd()
+		// update noise animation (--fps, or --idle-fps while idle)
+		dt := 1 / float64(m.frameRate())
+		m.updateIdleMix(dt)
+		m.noiseGen.Update(m.animationDelta(dt))
+		return m, tickCmd(m.frameRate())
 
 	case audioMsg:
 		// updates with new audio data
-		m.bands = msg.Bands
-		m.chaosLevel = msg.ChaosLevel
 		m.metadata = msg.Metadata
+		m.bpm = msg.BPM
+		m.tempoConf = msg.TempoConfidence
+		m.loudness = msg.Loudness
+		m.gain = msg.Gain
+		m.idle = msg.Idle
+		// Nothing to draw while the idle animation covers the screen. Otherwise
+		// all of them, so a view is up to date when TAB brings it back.
+		if m.idle && m.idleMix >= 1 {
+			return m, waitForAudio(m.frameChan)
+		}
+		for _, v := range m.visualizers {
+			v.Update(AudioFrame(msg))
+		}
 
 		return m, waitForAudio(m.frameChan)
 
+	case statusMsg:
+		m.status = CaptureStatus(msg)
+		LogInfo("Capture status: state=%d detail=%s", m.status.State, m.status.Detail)
+		return m, waitForStatus(m.statusChan)
+
 	case tea.QuitMsg:
 		LogInfo("Received tea.QuitMsg")
 		return m, tea.Quit
@@ -118,13 +251,36 @@ func (m model) View() string {
 
 	// Calculate section heights
 	metadataHeight := int(float64(m.height) * 0.3)
-	waveHeight := m.height - metadataHeight - 2 // -2 for footer
+	footerLines := 2
+	if m.status.State == CaptureReconnecting {
+		footerLines++ // reconnect banner
+	}
+	waveHeight := m.height - metadataHeight - footerLines
 
 	// Render metadata section (top 30%)
-	metadata := RenderMetadata(m.metadata, m.width, metadataHeight)
+	// Loudness meter next to the metadata when enabled and there's room
+	var metadata string
+	if m.showMeter && m.width >= meterPanelWidth*2 {
+		metadata = lipgloss.JoinHorizontal(lipgloss.Top,
+			RenderMetadata(m.metadata, m.bpm, m.tempoConf, m.width-meterPanelWidth, metadataHeight),
+			RenderLoudnessPanel(m.loudness, meterPanelWidth, metadataHeight),
+		)
+	} else {
+		metadata = RenderMetadata(m.metadata, m.bpm, m.tempoConf, m.width, metadataHeight)
+	}
 
-	// Render horizontal plasma beams (bottom 70%)
-	waves := m.beamRenderer.RenderPlasmaBeams(m.bands, m.chaosLevel, m.width, waveHeight)
+	// Render the selected visualization (bottom 70%), or the idle animation
+	// while nothing plays. The change goes through black: the first half of
+	// idleMix dims the visualization, the second half brings up the animation.
+	var waves string
+	switch {
+	case m.idleMix >= 0.5:
+		waves = m.idleRenderer.Render(m.width, waveHeight, 2*m.idleMix-1)
+	case m.idleMix > 0:
+		waves = dimANSI(m.visualizers[m.viewMode].Render(m.width, waveHeight), 1-2*m.idleMix)
+	default:
+		waves = m.visualizers[m.viewMode].Render(m.width, waveHeight)
+	}
 
 	// Footer
 	schemeLabel := "Original"
@@ -132,10 +288,45 @@ func (m model) View() string {
 		schemeLabel = "Retro"
 	}
 
+	footerText := fmt.Sprintf("\nPress 'q' to quit | SPACE to change colors | TAB view: %s | m meter | %d FPS | %s", m.viewMode, m.frameRate(), schemeLabel)
+	if m.idle {
+		footerText += " | Idle"
+	}
+	if m.gain > 0 {
+		footerText += fmt.Sprintf(" | Gain %+.1f dB", 20*math.Log10(m.gain))
+	}
+	if m.playback != nil {
+		pos, total := m.playback.Position()
+		state := "▶"
+		if m.playback.Paused() {
+			state = "▌▌"
+		}
+		length := formatPlaybackTime(total)
+		if total == 0 {
+			length = "-:--" // a FLAC stream that doesn't state its length
+		}
+		footerText += fmt.Sprintf(" | %s %s / %s (p pause, ←/→ seek)", state, formatPlaybackTime(pos), length)
+	}
+	if m.apps != nil {
+		app := m.apps.CaptureApp()
+		if app == "" {
+			app = "all"
+		}
+		footerText += fmt.Sprintf(" | App: %s (a to switch)", app)
+	}
+
 	footer := lipgloss.NewStyle().
 		Faint(true).
 		Foreground(lipgloss.Color("#888888")).
-		Render("\nPress 'q' to quit | SPACE to change colors | 60 FPS | " + schemeLabel)
+		Render(footerText)
+
+	if m.status.State == CaptureReconnecting {
+		banner := lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("#FFD400")).
+			Render("\n⟳ Reconnecting audio capture: " + truncateString(m.status.Detail, max(m.width-32, 10)))
+		footer = banner + footer
+	}
 
 	return fmt.Sprintf("%s\n%s%s", metadata, waves, footer)
 }
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	tunerHold      = 1500 * time.Millisecond // keep showing the last note this long after it stops
+	tunerMinClear  = 0.6                     // pitch clarity needed to move the needle
+	tunerInTune    = 5.0                     // cents either way that count as in tune
+	tunerRange     = 50.0                    // cents at the ends of the scale
+	tunerSmoothing = 0.3                     // needle easing per analysis frame
+)
+
+// TunerRenderer shows the nearest note, its frequency and how many cents the
+// input is off, with a needle on a -50..+50 cent scale
+type TunerRenderer struct {
+	reference float64 // A4 in Hz
+	note      int     // MIDI note number, -1 before the first note
+	frequency float64
+	cents     float64 // smoothed
+	lastHeard time.Time
+}
+
+func NewTunerRenderer() *TunerRenderer {
+	return &TunerRenderer{reference: 440, note: -1}
+}
+
+// SetReference changes the pitch of A4
+func (tr *TunerRenderer) SetReference(a4 float64) {
+	tr.reference = a4
+}
+
+// Update takes the detected pitch of a new analysis frame
+func (tr *TunerRenderer) Update(frame AudioFrame) {
+	pitch := frame.Pitch
+	if pitch <= 0 || frame.PitchClarity < tunerMinClear {
+		return
+	}
+	midi := 69 + 12*math.Log2(pitch/tr.reference)
+	note := int(math.Round(midi))
+	cents := (midi - float64(note)) * 100
+
+	// Jump straight to a new note, ease the needle on the same one
+	if note != tr.note {
+		tr.note, tr.cents = note, cents
+	} else {
+		tr.cents += (cents - tr.cents) * tunerSmoothing
+	}
+	tr.frequency = pitch
+	tr.lastHeard = time.Now()
+}
+
+// SetColorScheme does nothing, the needle colors say how far off the note is
+func (tr *TunerRenderer) SetColorScheme(string) {}
+
+// Reset forgets the last note
+func (tr *TunerRenderer) Reset() {
+	tr.note, tr.frequency, tr.cents = -1, 0, 0
+}
+
+func (tr *TunerRenderer) Render(width, height int) string {
+	active := tr.note >= 0 && time.Since(tr.lastHeard) < tunerHold
+
+	color := lipgloss.Color("#555555")
+	noteText, detail := "—", fmt.Sprintf("play a note  (A4 = %.0f Hz)", tr.reference)
+	if active {
+		color = tunerColor(tr.cents)
+		noteText = fmt.Sprintf("%s%d", pitchClassNames[(tr.note%12+12)%12], tr.note/12-1)
+		detail = fmt.Sprintf("%7.2f Hz   %+5.1f cents", tr.frequency, tr.cents)
+	}
+
+	center := lipgloss.NewStyle().Width(width).Align(lipgloss.Center)
+	lines := []string{
+		center.Render(lipgloss.NewStyle().Bold(true).Foreground(color).Render(spacedNote(noteText))),
+		"",
+		center.Render(lipgloss.NewStyle().Foreground(lipgloss.Color("#AAAAAA")).Render(detail)),
+		"",
+	}
+	for _, row := range tr.renderScale(min(width-4, 61), active, color) {
+		lines = append(lines, center.Render(row))
+	}
+
+	// Centre vertically
+	top := max((height-len(lines))/2, 0)
+	out := make([]string, 0, height)
+	for range top {
+		out = append(out, "")
+	}
+	out = append(out, lines...)
+	for len(out) < height {
+		out = append(out, "")
+	}
+	return strings.Join(out[:height], "\n")
+}
+
+// renderScale draws the cent scale with the needle over it
+func (tr *TunerRenderer) renderScale(width int, active bool, color lipgloss.Color) []string {
+	if width < 11 {
+		return nil
+	}
+	if width%2 == 0 {
+		width-- // so 0 cents has a column of its own
+	}
+	mid := width / 2
+	column := func(cents float64) int {
+		return mid + int(math.Round(cents/tunerRange*float64(mid)))
+	}
+	needle := -1
+	if active {
+		needle = column(math.Max(-tunerRange, math.Min(tunerRange, tr.cents)))
+	}
+
+	labels := []rune(strings.Repeat(" ", width))
+	for _, c := range []float64{-50, -25, 0, 25, 50} {
+		text := fmt.Sprintf("%+.0f", c)
+		if c == 0 {
+			text = "0"
+		}
+		start := min(max(column(c)-len(text)/2, 0), width-len(text))
+		copy(labels[start:], []rune(text))
+	}
+
+	zone := lipgloss.NewStyle().Foreground(lipgloss.Color("#3DFF4E"))
+	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("#666666"))
+	needleStyle := lipgloss.NewStyle().Foreground(color).Bold(true)
+
+	var ticks, needleRow strings.Builder
+	lo, hi := column(-tunerInTune), column(tunerInTune)
+	for x := range width {
+		tick := "─"
+		switch {
+		case x == mid:
+			tick = "┼"
+		case (x-mid)%max(mid/5, 1) == 0:
+			tick = "┴"
+		}
+		switch {
+		case x == needle:
+			ticks.WriteString(needleStyle.Render("█"))
+		case x >= lo && x <= hi:
+			ticks.WriteString(zone.Render(tick))
+		default:
+			ticks.WriteString(dim.Render(tick))
+		}
+
+		if x == needle {
+			needleRow.WriteString(needleStyle.Render("▲"))
+		} else {
+			needleRow.WriteByte(' ')
+		}
+	}
+
+	flat, sharp := "♭", "♯"
+	if active && tr.cents < -tunerInTune {
+		flat = needleStyle.Render("◀ ♭")
+	}
+	if active && tr.cents > tunerInTune {
+		sharp = needleStyle.Render("♯ ▶")
+	}
+	return []string{
+		dim.Render(string(labels)),
+		ticks.String(),
+		needleRow.String(),
+		flat + strings.Repeat(" ", max(width-lipgloss.Width(flat)-lipgloss.Width(sharp), 1)) + sharp,
+	}
+}
+
+// tunerColor is green in tune, yellow close and red far off
+func tunerColor(cents float64) lipgloss.Color {
+	switch off := math.Abs(cents); {
+	case off <= tunerInTune:
+		return lipgloss.Color("#3DFF4E")
+	case off <= 15:
+		return lipgloss.Color("#FFD400")
+	default:
+		return lipgloss.Color("#FF3030")
+	}
+}
+
+// spacedNote spreads a note name out a bit so it stands out, "C#4" -> "C # 4"
+func spacedNote(s string) string {
+	return strings.Join(strings.Split(s, ""), " ")
+}
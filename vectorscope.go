@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	vectorscopeFrames = 6    // analysis frames kept on screen, older ones fade
+	vectorscopeDecay  = 0.98 // per frame fall of the auto gain
+	vectorscopeFloor  = 1e-3 // gain stops rising below this level
+)
+
+// Newest to oldest persistence colors per scheme
+var (
+	vectorscopeColors = []lipgloss.Color{"#E0FFFF", "#00FFFF", "#00D7FF", "#00AFD7", "#0087AF", "#005F87"}
+	vectorscopeRetro  = []lipgloss.Color{"#FFFFAF", "#FFD75F", "#FF8700", "#FF5F00", "#D70000", "#870000"}
+	vectorscopeAxis   = lipgloss.Color("#3A3A3A")
+)
+
+// VectorscopeRenderer draws a goniometer: L/R sample pairs rotated by 45
+// degrees so mono material is a vertical line and out of phase material a
+// horizontal one
+type VectorscopeRenderer struct {
+	history [][][2]float32 // point sets of the last frames, newest last
+	level   float64        // auto gain, tracks the peak excursion
+	stereo  StereoImage    // the latest frame, for the readout
+	colors  []lipgloss.Color
+	canvas  *BrailleCanvas
+	cache   *RenderCache
+}
+
+func NewVectorscopeRenderer() *VectorscopeRenderer {
+	return &VectorscopeRenderer{
+		level:  vectorscopeFloor,
+		colors: vectorscopeColors,
+		canvas: NewBrailleCanvas(0, 0),
+		cache:  NewRenderCache(),
+	}
+}
+
+func (vr *VectorscopeRenderer) SetColorScheme(scheme string) {
+	if scheme == "retro" {
+		vr.colors = vectorscopeRetro
+	} else {
+		vr.colors = vectorscopeColors
+	}
+}
+
+// Update pushes the frame's sample pairs and keeps its stereo image
+func (vr *VectorscopeRenderer) Update(frame AudioFrame) {
+	// The readout only needs the measurements, the slices go back to the processor
+	vr.stereo = frame.Stereo
+	vr.stereo.BandsLeft, vr.stereo.BandsRight, vr.stereo.Points = nil, nil, nil
+	vr.Push(frame.Stereo.Points)
+}
+
+// Reset empties the persistence and the auto gain
+func (vr *VectorscopeRenderer) Reset() {
+	vr.history = nil
+	vr.level = vectorscopeFloor
+	vr.stereo = StereoImage{}
+}
+
+// Push adds a copy of the sample pairs of a new analysis frame
+func (vr *VectorscopeRenderer) Push(points [][2]float32) {
+	peak := 0.0
+	for _, p := range points {
+		mid, side := midSide(p)
+		peak = math.Max(peak, math.Max(math.Abs(mid), math.Abs(side)))
+	}
+	vr.level = math.Max(math.Max(peak, vr.level*vectorscopeDecay), vectorscopeFloor)
+
+	// Reuse the buffer of the oldest set once the history is full
+	var set [][2]float32
+	if len(vr.history) == vectorscopeFrames {
+		set = vr.history[0]
+		copy(vr.history, vr.history[1:])
+		vr.history = vr.history[:vectorscopeFrames-1]
+	}
+	vr.history = append(vr.history, append(set[:0], points...))
+}
+
+// midSide rotates an L/R pair, mid points up and side to the right
+func midSide(p [2]float32) (float64, float64) {
+	l, r := float64(p[0]), float64(p[1])
+	return (l + r) / math.Sqrt2, (r - l) / math.Sqrt2
+}
+
+// Render draws the scope with a correlation and balance readout underneath
+func (vr *VectorscopeRenderer) Render(width, height int) string {
+	if width < 4 || height < 3 {
+		return ""
+	}
+	scopeHeight := height - 1
+	vr.canvas.Resize(width, scopeHeight)
+	dotsW, dotsH := vr.canvas.DotSize()
+
+	// Square plot centred in the canvas, dots are about square
+	size := min(dotsW, dotsH)
+	cx, cy := dotsW/2, dotsH/2
+	radius := float64(size-1) / 2
+
+	// L, R and mono axes
+	half := int(radius)
+	vr.canvas.Line(cx, cy-half, cx, cy+half, vectorscopeAxis)
+	vr.canvas.Line(cx-half, cy-half, cx+half, cy+half, vectorscopeAxis)
+	vr.canvas.Line(cx+half, cy-half, cx-half, cy+half, vectorscopeAxis)
+
+	scale := radius * 0.9 / vr.level
+	for age := range vr.history {
+		color := vr.colors[min(len(vr.history)-1-age, len(vr.colors)-1)]
+		for _, p := range vr.history[age] {
+			mid, side := midSide(p)
+			x := cx + int(math.Round(side*scale))
+			y := cy - int(math.Round(mid*scale))
+			vr.canvas.Set(x, y, color)
+		}
+	}
+
+	return vr.canvas.String(vr.cache) + "\n" + vr.renderReadout(vr.stereo, width)
+}
+
+// renderReadout is the correlation meter plus balance and M/S figures
+func (vr *VectorscopeRenderer) renderReadout(stereo StereoImage, width int) string {
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#00FFFF")).Bold(true)
+	values := fmt.Sprintf(" %+.2f  Bal %s  M/S %.2f", stereo.Correlation, formatBalance(stereo.Balance), stereo.MidSide)
+
+	barWidth := width - lipgloss.Width(values) - 10
+	if barWidth < 5 {
+		return lipgloss.NewStyle().MaxWidth(width).Render(labelStyle.Render("Corr") + values)
+	}
+
+	// -1 .. +1 with the marker where the correlation sits
+	marker := int(math.Round((stereo.Correlation + 1) / 2 * float64(barWidth-1)))
+	var bar strings.Builder
+	for i := range barWidth {
+		switch {
+		case i == marker:
+			bar.WriteString("●")
+		case i == barWidth/2:
+			bar.WriteString("┼")
+		default:
+			bar.WriteString("─")
+		}
+	}
+
+	return labelStyle.Render("Corr") + " -1 " +
+		lipgloss.NewStyle().Foreground(correlationColor(stereo.Correlation)).Render(bar.String()) +
+		" +1" + values
+}
+
+// correlationColor is red for phase problems, yellow for wide and green for
+// mono compatible material
+func correlationColor(c float64) lipgloss.Color {
+	switch {
+	case c < 0:
+		return lipgloss.Color("#FF3030")
+	case c < 0.3:
+		return lipgloss.Color("#FFD400")
+	default:
+		return lipgloss.Color("#3DFF4E")
+	}
+}
+
+// formatBalance prints the balance as "C", "L 25" or "R 40" (percent)
+func formatBalance(b float64) string {
+	pct := int(math.Round(math.Abs(b) * 100))
+	switch {
+	case pct == 0:
+		return "C   "
+	case b < 0:
+		return fmt.Sprintf("L%3d", pct)
+	default:
+		return fmt.Sprintf("R%3d", pct)
+	}
+}
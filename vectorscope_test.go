@@ -0,0 +1,33 @@
+package main
+
+import "testing"
+
+// The processor rewrites a frame's points later, the persistence sets must
+// not change with them
+func TestVectorscopeKeepsCopies(t *testing.T) {
+	vr := NewVectorscopeRenderer()
+
+	points := make([][2]float32, 16)
+	for i := range vectorscopeFrames + 2 {
+		for j := range points {
+			points[j] = [2]float32{float32(i), -float32(i)}
+		}
+		vr.Update(AudioFrame{Stereo: StereoImage{Points: points, Correlation: 1}})
+	}
+
+	if len(vr.history) != vectorscopeFrames {
+		t.Fatalf("kept %d sets, want %d", len(vr.history), vectorscopeFrames)
+	}
+	for age, set := range vr.history {
+		want := [2]float32{float32(2 + age), -float32(2 + age)}
+		for _, p := range set {
+			if p != want {
+				t.Errorf("set %d holds %v, want %v", age, p, want)
+				break
+			}
+		}
+	}
+	if vr.stereo.Points != nil || vr.stereo.Correlation != 1 {
+		t.Errorf("readout kept %+v", vr.stereo)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Visualizer is one of the views in the lower part of the screen. Every
+// registered visualizer gets every analysis frame, so switching views keeps
+// each one's state (smoothing, history, the last note) instead of starting over.
+type Visualizer interface {
+	// Update takes a new analysis frame
+	Update(frame AudioFrame)
+	// Render draws the view into width x height terminal cells
+	Render(width, height int) string
+	// SetColorScheme switches between the "original" and "retro" palettes
+	SetColorScheme(scheme string)
+	// Reset drops everything accumulated from earlier frames
+	Reset()
+}
+
+// visualizerModes in TAB order, the first is the default
+var visualizerModes = []struct {
+	name   string
+	create func(noiseGen *NoiseGenerator) Visualizer
+}{
+	{"beams", func(ng *NoiseGenerator) Visualizer { return NewBeamRenderer(ng) }},
+	{"strands", func(ng *NoiseGenerator) Visualizer { return NewStrandRenderer(ng) }},
+	{"bars", func(*NoiseGenerator) Visualizer { return NewBarsRenderer() }},
+	{"scope", func(*NoiseGenerator) Visualizer { return NewOscilloscopeRenderer() }},
+	{"waterfall", func(*NoiseGenerator) Visualizer { return NewWaterfallRenderer() }},
+	{"radial", func(ng *NoiseGenerator) Visualizer { return NewRadialRenderer(ng) }},
+	{"vectorscope", func(*NoiseGenerator) Visualizer { return NewVectorscopeRenderer() }},
+	{"chroma", func(*NoiseGenerator) Visualizer { return NewChromaWheelRenderer() }},
+	{"tuner", func(*NoiseGenerator) Visualizer { return NewTunerRenderer() }},
+}
+
+// visualizerNames lists the registered modes in TAB order
+func visualizerNames() []string {
+	names := make([]string, len(visualizerModes))
+	for i, mode := range visualizerModes {
+		names[i] = mode.name
+	}
+	return names
+}
+
+// NewVisualizers creates one of each registered visualizer, sharing the noise
+// generator so they all animate on the same clock
+func NewVisualizers(noiseGen *NoiseGenerator) map[string]Visualizer {
+	visualizers := make(map[string]Visualizer, len(visualizerModes))
+	for _, mode := range visualizerModes {
+		visualizers[mode.name] = mode.create(noiseGen)
+	}
+	return visualizers
+}
+
+// ValidateVisualizer checks a --mode value
+func ValidateVisualizer(name string) error {
+	for _, mode := range visualizerModes {
+		if mode.name == name {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown mode %q (available: %s)", name, strings.Join(visualizerNames(), ", "))
+}
+
+// nextVisualizer is the mode after current in TAB order, or before it for a
+// negative step
+func nextVisualizer(current string, step int) string {
+	names := visualizerNames()
+	for i, name := range names {
+		if name == current {
+			return names[((i+step)%len(names)+len(names))%len(names)]
+		}
+	}
+	return names[0]
+}
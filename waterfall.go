@@ -0,0 +1,220 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	defaultWaterfallSpan = 10 * time.Second
+	maxWaterfallSpan     = time.Minute // history costs about 90KB a second at the default hop
+	defaultDBFloor       = -90.0
+	defaultDBCeiling     = -10.0
+)
+
+// Quiet to loud, stretched over waterfallShades
+var (
+	waterfallHeat   = []lipgloss.Color{"#0D0221", "#3B0F70", "#8C2981", "#DE4968", "#FE9F6D", "#FCFDBF"}
+	waterfallIce    = []lipgloss.Color{"#020A1A", "#0B2E59", "#1763A6", "#2AA9D2", "#8FE3F0", "#F0FFFF"}
+	waterfallLabels = lipgloss.Color("#888888")
+)
+
+const waterfallShades = 48
+
+// Frequencies labelled on the axis, the smaller ones only when there's room
+var waterfallTicks = []struct {
+	freq  float64
+	label string
+	major bool
+}{
+	{50, "50", false}, {100, "100", true}, {200, "200", false}, {500, "500", false},
+	{1000, "1k", true}, {2000, "2k", false}, {5000, "5k", false}, {10000, "10k", true},
+}
+
+// spectrumLine is one frame's spectrum and when it was analyzed
+type spectrumLine struct {
+	at       time.Time
+	spectrum []float32
+}
+
+// WaterfallRenderer is a scrolling spectrogram: the recent spectra side by
+// side on a log frequency axis, loudness as color. Vertically the newest
+// spectrum is the top row, horizontally the rightmost column.
+type WaterfallRenderer struct {
+	history    []spectrumLine // oldest first, no older than span
+	spare      [][]float32    // spectra of dropped lines, reused for new ones
+	span       time.Duration
+	floor      float64 // dBFS at the bottom of the palette
+	ceiling    float64 // dBFS at the top
+	horizontal bool
+	palette    []lipgloss.Color
+	canvas     *HalfBlockCanvas
+	cache      *RenderCache
+
+	// The last output, only new spectra change it
+	lastOutput string
+	lastWidth  int
+	lastHeight int
+	dirty      bool
+}
+
+func NewWaterfallRenderer() *WaterfallRenderer {
+	return &WaterfallRenderer{
+		span:    defaultWaterfallSpan,
+		floor:   defaultDBFloor,
+		ceiling: defaultDBCeiling,
+		palette: spreadPalette(waterfallHeat, waterfallShades),
+		canvas:  NewHalfBlockCanvas(0, 0),
+		cache:   NewRenderCache(),
+		dirty:   true,
+	}
+}
+
+// SetRange sets the dBFS levels mapped to the ends of the palette
+func (wr *WaterfallRenderer) SetRange(floor, ceiling float64) {
+	wr.floor, wr.ceiling = floor, ceiling
+	wr.dirty = true
+}
+
+// SetSpan sets how much time the display covers, up to maxWaterfallSpan
+func (wr *WaterfallRenderer) SetSpan(span time.Duration) {
+	wr.span = min(span, maxWaterfallSpan)
+	wr.dirty = true
+}
+
+// SetHorizontal scrolls right to left with frequency going up, instead of
+// top to bottom with frequency going right
+func (wr *WaterfallRenderer) SetHorizontal(horizontal bool) {
+	wr.horizontal = horizontal
+	wr.dirty = true
+}
+
+func (wr *WaterfallRenderer) SetColorScheme(scheme string) {
+	if scheme == "retro" {
+		wr.palette = spreadPalette(waterfallIce, waterfallShades)
+	} else {
+		wr.palette = spreadPalette(waterfallHeat, waterfallShades)
+	}
+	wr.dirty = true
+}
+
+// Update appends a copy of the frame's spectrum, the frame's own is reused by
+// the processor long before it scrolls off, and drops the ones that did
+func (wr *WaterfallRenderer) Update(frame AudioFrame) {
+	if len(frame.Spectrum) == 0 {
+		return
+	}
+	var spectrum []float32
+	if n := len(wr.spare); n > 0 {
+		spectrum, wr.spare = wr.spare[n-1][:0], wr.spare[:n-1]
+	}
+	spectrum = append(spectrum, frame.Spectrum...)
+	wr.history = append(wr.history, spectrumLine{at: frame.Timestamp, spectrum: spectrum})
+
+	cutoff := frame.Timestamp.Add(-wr.span)
+	drop := sort.Search(len(wr.history), func(i int) bool { return wr.history[i].at.After(cutoff) })
+	if drop > 0 {
+		for _, line := range wr.history[:drop] {
+			wr.spare = append(wr.spare, line.spectrum)
+		}
+		wr.history = append(wr.history[:0], wr.history[drop:]...)
+	}
+	wr.dirty = true
+}
+
+// Reset clears the display
+func (wr *WaterfallRenderer) Reset() {
+	wr.history, wr.spare = nil, nil
+	wr.dirty = true
+}
+
+func (wr *WaterfallRenderer) Render(width, height int) string {
+	if !wr.dirty && width == wr.lastWidth && height == wr.lastHeight {
+		return wr.lastOutput
+	}
+	wr.canvas.Resize(width, height)
+	pw, ph := wr.canvas.PixelSize()
+
+	timePixels, freqPixels := ph, pw
+	if wr.horizontal {
+		timePixels, freqPixels = pw, ph
+	}
+	if len(wr.history) > 0 && timePixels > 0 && freqPixels > 0 {
+		wr.draw(timePixels, freqPixels)
+		wr.drawLabels(width, height, freqPixels)
+	}
+
+	wr.lastOutput = wr.canvas.String(wr.cache)
+	wr.lastWidth, wr.lastHeight, wr.dirty = width, height, false
+	return wr.lastOutput
+}
+
+// draw fills the canvas, t counts pixels back from the newest spectrum and f
+// pixels up from the lowest frequency
+func (wr *WaterfallRenderer) draw(timePixels, freqPixels int) {
+	// Spectrum points each frequency pixel covers
+	points := len(wr.history[len(wr.history)-1].spectrum)
+	bounds := make([]int, freqPixels+1)
+	for f := range bounds {
+		bounds[f] = f * points / freqPixels
+	}
+
+	newest := wr.history[len(wr.history)-1].at
+	pixelTime := wr.span / time.Duration(timePixels)
+	scale := float64(len(wr.palette)-1) / (wr.ceiling - wr.floor)
+	for t := range timePixels {
+		// The latest spectrum at or before this pixel's time, skipped if the
+		// gap to it is more than a pixel (a pause, or the start)
+		at := newest.Add(-time.Duration(t) * pixelTime)
+		i := sort.Search(len(wr.history), func(i int) bool { return wr.history[i].at.After(at) }) - 1
+		if i < 0 || at.Sub(wr.history[i].at) > pixelTime {
+			continue
+		}
+		spectrum := wr.history[i].spectrum
+
+		for f := range freqPixels {
+			lo, hi := bounds[f], max(bounds[f+1], bounds[f]+1)
+			db := float64(spectrum[min(lo, len(spectrum)-1)])
+			for _, v := range spectrum[lo:min(hi, len(spectrum))] {
+				db = math.Max(db, float64(v))
+			}
+			shade := int((db - wr.floor) * scale)
+			if shade <= 0 {
+				continue
+			}
+			color := wr.palette[min(shade, len(wr.palette)-1)]
+			if wr.horizontal {
+				wr.canvas.Set(timePixels-1-t, freqPixels-1-f, color)
+			} else {
+				wr.canvas.Set(f, t, color)
+			}
+		}
+	}
+}
+
+// drawLabels marks the frequency axis, along the bottom row when scrolling
+// vertically and down the left edge when scrolling horizontally
+func (wr *WaterfallRenderer) drawLabels(width, height, freqPixels int) {
+	minor := width >= 60
+	if wr.horizontal {
+		minor = height >= 20
+	}
+	for _, tick := range waterfallTicks {
+		if !tick.major && !minor {
+			continue
+		}
+		pos := math.Log(tick.freq/spectrumMinFreq) / math.Log(spectrumMaxFreq/spectrumMinFreq) * float64(freqPixels)
+		if wr.horizontal {
+			row := (freqPixels - 1 - int(pos)) / 2
+			wr.canvas.Text(0, row, tick.label, waterfallLabels)
+		} else {
+			wr.canvas.TextCentered(int(pos), height-1, tick.label, waterfallLabels)
+		}
+	}
+	span := fmt.Sprintf("%gs", wr.span.Seconds())
+	wr.canvas.Text(max(width-len(span), 0), 0, span, waterfallLabels)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	waveformSpan       = 1024 // samples shown by the oscilloscope, about 23ms at 44.1kHz
+	waveformMaxPoints  = 512  // points per channel handed to the oscilloscope
+	waveformHysteresis = 0.01 // the signal has to come up from below this to trigger
+)
+
+// Waveform is a stretch of the time-domain signal for the oscilloscope,
+// started on a rising zero crossing so periodic sounds stand still
+type Waveform struct {
+	Left, Right []float32     // decimated samples, oldest first
+	Span        time.Duration // time covered
+	Triggered   bool          // false when no crossing was found and it free-runs
+}
+
+// waveform picks waveformSpan samples that start on a rising zero crossing of
+// the mono mix, searching back at most another span. The samples go in buf.
+func (ap *AudioProcessor) waveform(buf *frameBuffer) Waveform {
+	ringSize := len(ap.ringLeft)
+	span := min(waveformSpan, ringSize/2)
+	mono := func(back int) float64 {
+		pos := (ap.ringPos - back + ringSize) % ringSize
+		return ap.ringLeft[pos] + ap.ringRight[pos]
+	}
+
+	// back counts samples before ringPos, the window runs from there to
+	// back-span. Of the crossings, the steepest is the same point of every
+	// period, so signals with several crossings per period hold still too.
+	start, triggered := span, false
+	armed := false
+	steepest := 0.0
+	for back := 2 * span; back > span; back-- {
+		x := mono(back)
+		if x < -waveformHysteresis {
+			armed = true
+		}
+		if next := mono(back - 1); armed && x < 0 && next >= 0 {
+			if slope := next - x; slope > steepest*0.99 {
+				start, triggered, steepest = back-1, true, math.Max(slope, steepest)
+			}
+			armed = false
+		}
+	}
+
+	step := max(1, span/waveformMaxPoints)
+	wave := Waveform{
+		Left:      buf.waveLeft[:0],
+		Right:     buf.waveRight[:0],
+		Span:      time.Duration(span) * time.Second / time.Duration(ap.sampleRate),
+		Triggered: triggered,
+	}
+	for i := 0; i < span; i += step {
+		pos := (ap.ringPos - start + i + ringSize) % ringSize
+		wave.Left = append(wave.Left, float32(ap.ringLeft[pos]))
+		wave.Right = append(wave.Right, float32(ap.ringRight[pos]))
+	}
+	buf.waveLeft, buf.waveRight = wave.Left, wave.Right
+	return wave
+}
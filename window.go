@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Analysis windows selectable with --window
+var analysisWindows = []string{"hann", "hamming", "blackman-harris", "none"}
+
+// makeWindow returns the coefficients of a periodic window of the given size.
+// Periodic (rather than symmetric) windows are the right choice for
+// overlapping STFT frames.
+func makeWindow(name string, size int) ([]float64, error) {
+	w := make([]float64, size)
+	n := float64(size)
+
+	switch strings.ToLower(name) {
+	case "hann":
+		for i := range w {
+			w[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/n)
+		}
+	case "hamming":
+		for i := range w {
+			w[i] = 0.54 - 0.46*math.Cos(2*math.Pi*float64(i)/n)
+		}
+	case "blackman-harris":
+		// 4-term, -92dB sidelobes, keeps the bass out of the upper bands
+		const a0, a1, a2, a3 = 0.35875, 0.48829, 0.14128, 0.01168
+		for i := range w {
+			x := 2 * math.Pi * float64(i) / n
+			w[i] = a0 - a1*math.Cos(x) + a2*math.Cos(2*x) - a3*math.Cos(3*x)
+		}
+	case "none", "rect", "rectangular":
+		for i := range w {
+			w[i] = 1
+		}
+	default:
+		return nil, fmt.Errorf("unknown window %q (available: %s)", name, strings.Join(analysisWindows, ", "))
+	}
+
+	return w, nil
+}